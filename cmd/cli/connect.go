@@ -8,12 +8,13 @@ import (
 
 var host string
 var port string
+var protocolMode string
 
 var connectCmd = &cobra.Command{
 	Use:   "connect",
 	Short: "Connect to Dagenie TCP Client",
 	Run: func(cmd *cobra.Command, args []string) {
 		serverAddr := host + ":" + port
-		tcp.StartTCPClient(serverAddr)
+		tcp.StartTCPClient(serverAddr, protocolMode)
 	},
 }
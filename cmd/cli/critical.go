@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"dagenie/internal/dql/executor"
+	"dagenie/internal/dql/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var criticalCmd = &cobra.Command{
+	Use:   "critical",
+	Short: "Print the critical path of a DAG using task durations",
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Println("❌ Please provide a database path using --db flag")
+			os.Exit(1)
+		}
+
+		db, err := storage.OpenDatabase(dbPath)
+		if err != nil {
+			fmt.Printf("❌ Error opening DB at '%s': %v\n", dbPath, err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		table, err := db.Table(storage.DefaultTable)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		result, err := executor.CriticalPath(table, dagID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🔍 Critical path analysis of DAG: %s\n", dagID)
+		for _, s := range result.Schedule {
+			marker := "  "
+			if s.Critical {
+				marker = "🔥"
+			}
+			fmt.Printf("%s Task ID=%s Duration=%d EarliestStart=%d LatestStart=%d Slack=%d\n",
+				marker, s.Task.ID, s.Task.Duration, s.EarliestStart, s.LatestStart, s.Slack)
+		}
+
+		ids := make([]string, 0, len(result.Path))
+		for _, task := range result.Path {
+			ids = append(ids, task.ID)
+		}
+		fmt.Printf("🛤️ Critical path: %s\n", strings.Join(ids, " -> "))
+		fmt.Printf("⏱️ Total duration: %d\n", result.TotalDuration)
+		fmt.Println("✅ Critical path complete.")
+	},
+}
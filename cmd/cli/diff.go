@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"dagenie/internal/dql/executor"
+	"dagenie/internal/dql/format"
+	"dagenie/internal/dql/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var otherDAGID string
+var diffTable string
+var otherTable string
+var diffFormat string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two DAGs task by task",
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Println("❌ Please provide a database path using --db flag")
+			os.Exit(1)
+		}
+		if otherTable == "" {
+			otherTable = diffTable
+		}
+
+		db, err := storage.OpenDatabase(dbPath)
+		if err != nil {
+			fmt.Printf("❌ Failed to open DB: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		from, err := executor.LoadDAG(db, diffTable, dagID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		to, err := executor.LoadDAG(db, otherTable, otherDAGID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		diff := executor.DiffDAGs(from, to)
+
+		if diffFormat != "" {
+			formatter, err := format.Lookup(diffFormat)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := formatter.Format(os.Stdout, executor.DiffResult(diff)); err != nil {
+				fmt.Printf("❌ Output Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		fmt.Printf("🔍 Diff of DAG %s → %s\n", dagID, otherDAGID)
+		for _, task := range diff.AddedTasks {
+			fmt.Printf("➕ Task ID=%s Name=%s\n", task.ID, task.Name)
+		}
+		for _, task := range diff.RemovedTasks {
+			fmt.Printf("➖ Task ID=%s Name=%s\n", task.ID, task.Name)
+		}
+		for _, edge := range diff.AddedEdges {
+			fmt.Printf("➕ Edge %s → %s\n", edge.Dependency, edge.Task)
+		}
+		for _, edge := range diff.RemovedEdges {
+			fmt.Printf("➖ Edge %s → %s\n", edge.Dependency, edge.Task)
+		}
+		for _, change := range diff.Changes {
+			fmt.Printf("✏️  Task ID=%s %s: %s → %s\n", change.TaskID, change.Field, change.Old, change.New)
+		}
+		if diff.Empty() {
+			fmt.Println("✅ The DAGs are identical.")
+			return
+		}
+		fmt.Printf("✅ %s.\n", diff.Summary())
+	},
+}
@@ -3,6 +3,11 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+
+	"dagenie/internal/dql"
+	"dagenie/internal/dql/format"
+	"dagenie/internal/dql/storage"
 
 	"github.com/spf13/cobra"
 )
@@ -18,8 +23,12 @@ func main() {
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "db", "d", "./dagdb", "Path to BadgerDB directory")
 	connectCmd.Flags().StringVarP(&host, "host", "s", "localhost", "Address of TCP server")
 	connectCmd.Flags().StringVarP(&port, "port", "p", "9090", "Port of TCP server")
+	connectCmd.Flags().StringVar(&protocolMode, "mode", "json", "Wire protocol: json or text")
 	serveCmd.Flags().StringVar(&servePort, "port", "9090", "Port to run the TCP server on")
 	serveCmd.Flags().StringVar(&dbPath, "db", "", "Path to the database directory")
+	serveCmd.Flags().StringVar(&dataDir, "data-dir", "", "Directory holding the databases and their catalog (default $DAGENIE_DATA_DIR or ./data)")
+	serveCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", dql.DefaultIdleTimeout, "Close databases unused for this long")
+	serveCmd.Flags().DurationVar(&dql.TransactionIdleTimeout, "tx-idle-timeout", dql.DefaultTransactionIdleTimeout, "Roll back transactions idle for this long")
 	serveCmd.MarkFlagRequired("db")
 	deleteCmd.Flags().StringVarP(&deleteID, "id", "i", "", "Task ID to delete")
 	deleteCmd.Flags().StringVarP(&dagID, "dag", "", "", "DAG ID (required)")
@@ -35,12 +44,24 @@ func main() {
 	insertCmd.MarkFlagRequired("id")
 	insertCmd.MarkFlagRequired("name")
 	queryCmd.Flags().StringVar(&dqlQuery, "dql", "", "DQL query to execute")
+	queryCmd.Flags().StringVar(&outputFormat, "format", format.DefaultFormat, "Output format: "+strings.Join(format.Names(), ", "))
 	queryCmd.MarkFlagRequired("dql")
 	traverseCmd.Flags().StringVarP(&traverseRoot, "root", "r", "", "Root task ID to start traversal")
 	traverseCmd.Flags().StringVarP(&traverseMode, "mode", "m", "dfs", "Traversal mode: dfs or bfs")
 	traverseCmd.Flags().StringVar(&dbPath, "db", "", "Path to database (required)")
 	traverseCmd.MarkFlagRequired("root")
 	traverseCmd.MarkFlagRequired("db")
+	topoCmd.Flags().StringVar(&dagID, "dag", "", "DAG ID (required)")
+	topoCmd.MarkFlagRequired("dag")
+	criticalCmd.Flags().StringVar(&dagID, "dag", "", "DAG ID (required)")
+	criticalCmd.MarkFlagRequired("dag")
+	diffCmd.Flags().StringVar(&dagID, "dag", "", "DAG ID to compare from (required)")
+	diffCmd.Flags().StringVar(&otherDAGID, "with", "", "DAG ID to compare with (required)")
+	diffCmd.Flags().StringVar(&diffTable, "table", storage.DefaultTable, "Table holding the DAG")
+	diffCmd.Flags().StringVar(&otherTable, "with-table", "", "Table holding the other DAG (default --table)")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "", "Output format: "+strings.Join(format.Names(), ", ")+" (default human-readable)")
+	diffCmd.MarkFlagRequired("dag")
+	diffCmd.MarkFlagRequired("with")
 
 	// Register commands
 	rootCmd.AddCommand(createCmd)
@@ -51,6 +72,9 @@ func main() {
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(connectCmd)
 	rootCmd.AddCommand(traverseCmd)
+	rootCmd.AddCommand(topoCmd)
+	rootCmd.AddCommand(criticalCmd)
+	rootCmd.AddCommand(diffCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
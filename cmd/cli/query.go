@@ -4,13 +4,15 @@ import (
 	"fmt"
 	"os"
 
-	"dagenie/internal/dagdb"
 	"dagenie/internal/dql"
+	"dagenie/internal/dql/format"
+	"dagenie/internal/dql/storage"
 
 	"github.com/spf13/cobra"
 )
 
 var dqlQuery string
+var outputFormat string
 
 var queryCmd = &cobra.Command{
 	Use:   "query",
@@ -21,7 +23,7 @@ var queryCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		db, err := dagdb.OpenDAGDB(dbPath)
+		db, err := storage.OpenDatabase(dbPath)
 		if err != nil {
 			fmt.Printf("❌ Failed to open DB: %v\n", err)
 			os.Exit(1)
@@ -33,13 +35,22 @@ var queryCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
+		formatter, err := format.Lookup(outputFormat)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
 		result, err := dql.ExecuteDQL(db, dqlQuery)
 		if err != nil {
 			fmt.Printf("❌ Execution Error: %v\n", err)
 			os.Exit(1)
 		}
 
-		fmt.Println(result)
+		if err := formatter.Format(os.Stdout, result); err != nil {
+			fmt.Printf("❌ Output Error: %v\n", err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -1,15 +1,19 @@
 package main
 
 import (
-	"dagenie/internal/dagdb"
+	"dagenie/internal/dql"
+	"dagenie/internal/dql/storage"
 	"dagenie/internal/tcp"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var servePort string
+var idleTimeout time.Duration
+var dataDir string
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
@@ -20,7 +24,7 @@ var serveCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		db, err := dagdb.OpenDAGDB(dbPath)
+		db, err := storage.OpenDatabase(dbPath)
 		if err != nil {
 			fmt.Printf("❌ Error opening DB at '%s': %v\n", dbPath, err)
 			os.Exit(1)
@@ -30,7 +34,21 @@ var serveCmd = &cobra.Command{
 		address := ":" + servePort
 		fmt.Printf("🚀 Starting Dagenie server on port %s using DB: %s\n", servePort, dbPath)
 
-		err = tcp.StartTCPServer(db, address)
+		if dataDir == "" {
+			dataDir = os.Getenv("DAGENIE_DATA_DIR")
+		}
+		if dataDir == "" {
+			dataDir = "./data"
+		}
+		manager, err := dql.NewManager(dataDir, idleTimeout)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		defer manager.Close()
+		fmt.Printf("📂 Databases are stored in %s\n", manager.Root())
+
+		err = tcp.StartTCPServer(db, manager, address)
 		if err != nil {
 			fmt.Println("❌ TCP Server error:", err)
 			os.Exit(1)
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"dagenie/internal/dql/executor"
+	"dagenie/internal/dql/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var topoCmd = &cobra.Command{
+	Use:   "topo",
+	Short: "Print the tasks of a DAG in execution order with their levels",
+	Run: func(cmd *cobra.Command, args []string) {
+		if dbPath == "" {
+			fmt.Println("❌ Please provide a database path using --db flag")
+			os.Exit(1)
+		}
+
+		db, err := storage.OpenDatabase(dbPath)
+		if err != nil {
+			fmt.Printf("❌ Error opening DB at '%s': %v\n", dbPath, err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		table, err := db.Table(storage.DefaultTable)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		order, err := executor.TopologicalOrder(table, dagID)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("🔍 Execution order of DAG: %s\n", dagID)
+		for _, tl := range order {
+			fmt.Printf("➡️  Level=%d Task ID=%s Name=%s Status=%s\n", tl.Level, tl.Task.ID, tl.Task.Name, tl.Task.Status)
+		}
+		fmt.Println("✅ Topological order complete.")
+	},
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/storage"
 	"fmt"
 	"os"
 
@@ -22,15 +23,21 @@ var traverseCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		db, err := dagdb.OpenDAGDB(dbPath)
+		database, err := storage.OpenDatabase(dbPath)
 		if err != nil {
 			fmt.Printf("❌ Error opening DB at '%s': %v\n", dbPath, err)
 			os.Exit(1)
 		}
-		defer db.Close()
+		defer database.Close()
+		db := database.Main()
 
-		// Load tasks into graph
-		tasks, err := db.ListAllTasks()
+		// Load the tasks of the dag table into graph
+		table, err := database.Table(storage.DefaultTable)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		tasks, err := table.ListAllTasks()
 		if err != nil {
 			fmt.Println("❌ Failed to load tasks into graph:", err)
 			os.Exit(1)
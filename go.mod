@@ -2,13 +2,15 @@ module dagenie
 
 go 1.24.1
 
-require github.com/dgraph-io/badger/v4 v4.6.0
+require (
+	github.com/chzyer/readline v1.5.1
+	github.com/dgraph-io/badger/v4 v4.6.0
+	github.com/olekukonko/tablewriter v0.0.5
+)
 
 require (
-	github.com/chzyer/readline v1.5.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-runewidth v0.0.9 // indirect
-	github.com/olekukonko/tablewriter v0.0.5 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 )
 
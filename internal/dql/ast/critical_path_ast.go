@@ -0,0 +1,7 @@
+package ast
+
+// CriticalPathQueryAST represents a CRITICAL PATH OF DAG '...' [IN TABLE t] query
+type CriticalPathQueryAST struct {
+	DAGID string
+	Table string
+}
@@ -0,0 +1,14 @@
+package ast
+
+// DAGQueryAST represents a statement on a whole DAG:
+//
+//	COPY DAG 'a' TO 'b' [IN TABLE t]
+//	RENAME DAG 'a' TO 'b' [IN TABLE t]
+//	DROP DAG 'a' [IN TABLE t]
+//	SHOW DAGS [IN TABLE t]
+type DAGQueryAST struct {
+	Action string // COPY, RENAME, DROP or SHOW
+	DAGID  string // DAG the statement works on, empty for SHOW
+	Target string // New DAG ID for COPY and RENAME
+	Table  string
+}
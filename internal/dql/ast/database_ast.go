@@ -0,0 +1,8 @@
+package ast
+
+// CreateDatabaseAST represents CREATE DATABASE name [OWNER 'o'] [WITH (k = v, ...)]
+type CreateDatabaseAST struct {
+	Name    string
+	Owner   string
+	Options map[string]string
+}
@@ -1,7 +1,10 @@
 package ast
 
-// DeleteQueryAST represents a DELETE ... WHERE ... query
+// DeleteQueryAST represents a DELETE ... WHERE ... [CASCADE | DETACH]
+// [RETURNING ...] query
 type DeleteQueryAST struct {
-	Table      string            // e.g., "dag"
-	Conditions map[string]string // WHERE conditions
+	Table     string      // e.g., "dag"
+	Where     LogicalNode // WHERE tree, nil when absent
+	Mode      string      // "", "CASCADE" or "DETACH": what happens to dependent tasks
+	Returning []string    // RETURNING fields, nil when absent, ["*"] for all
 }
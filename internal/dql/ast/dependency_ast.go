@@ -0,0 +1,12 @@
+package ast
+
+// DependencyQueryAST represents ADD DEPENDENCY 'a' TO 'b' or
+// REMOVE DEPENDENCY 'a' FROM 'b', optionally followed by IN DAG '...' and
+// IN TABLE t
+type DependencyQueryAST struct {
+	Action     string // ADD or REMOVE
+	Dependency string // Task that must run first
+	TaskID     string // Task whose dependency list changes
+	DAGID      string // Empty to look the task up across DAGs
+	Table      string
+}
@@ -0,0 +1,10 @@
+package ast
+
+// DiffQueryAST represents DIFF DAG 'a' [IN TABLE t] WITH 'b' [IN TABLE u].
+// OtherTable defaults to Table.
+type DiffQueryAST struct {
+	DAGID      string
+	Table      string
+	OtherDAGID string
+	OtherTable string
+}
@@ -1,8 +1,23 @@
 // ast/insert_ast.go
 package ast
 
+// InsertQueryAST represents
+// INSERT INTO table [(columns)] VALUES (row), ... [ON CONFLICT ...]
+// [RETURNING fields]
+// Columns is nil when the statement has no column list. Every row has the
+// same number of values.
 type InsertQueryAST struct {
-	Table   string
-	Columns []string
-	Values  []string
+	Table      string
+	Columns    []string
+	Rows       [][]Literal
+	OnConflict *OnConflictAST // nil without ON CONFLICT
+	Returning  []string       // RETURNING fields, nil when absent, ["*"] for all
+}
+
+// OnConflictAST is ON CONFLICT [(dagid, id)] DO NOTHING | DO UPDATE SET ...
+// A conflict is a row whose (dagid, id) task key already exists. For DO
+// UPDATE, SetFields holds the assignments in statement order.
+type OnConflictAST struct {
+	DoNothing bool
+	SetFields []Assignment
 }
@@ -0,0 +1,123 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// JSONPath is a path into a JSON document such as $.retries.backoff,
+// $.steps[0].name or $."team name": the object keys and array indexes
+// followed from the root $. Text is the path as written.
+type JSONPath struct {
+	Text  string
+	Steps []PathStep
+}
+
+// PathStep is one step of a JSONPath: an object key, or an array index
+// when IsIndex is set.
+type PathStep struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// ParseJSONPath parses a JSON path.
+func ParseJSONPath(text string) (JSONPath, error) {
+	path := JSONPath{Text: text}
+	src := []rune(text)
+	if len(src) == 0 || src[0] != '$' {
+		return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': it must start with $", text)
+	}
+	for i := 1; i < len(src); {
+		switch src[i] {
+		case '.':
+			i++
+			if i < len(src) && src[i] == '"' {
+				end := i + 1
+				for end < len(src) && src[end] != '"' {
+					end++
+				}
+				if end == len(src) {
+					return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': unterminated quoted key", text)
+				}
+				path.Steps = append(path.Steps, PathStep{Key: string(src[i+1 : end])})
+				i = end + 1
+				continue
+			}
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '-' || unicode.IsLetter(src[i]) || unicode.IsDigit(src[i])) {
+				i++
+			}
+			if i == start {
+				return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': expected a key after '.'", text)
+			}
+			path.Steps = append(path.Steps, PathStep{Key: string(src[start:i])})
+		case '[':
+			end := i + 1
+			for end < len(src) && src[end] != ']' {
+				end++
+			}
+			if end == len(src) {
+				return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': unterminated '['", text)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(string(src[i+1 : end])))
+			if err != nil || index < 0 {
+				return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': array index must be a non-negative integer", text)
+			}
+			path.Steps = append(path.Steps, PathStep{Index: index, IsIndex: true})
+			i = end + 1
+		default:
+			return JSONPath{}, fmt.Errorf("Invalid JSON path '%s': unexpected %q", text, src[i])
+		}
+	}
+	return path, nil
+}
+
+// PathField is a field followed by a JSON path operator, as in
+// payload->'$.owner'. Arrow "->" yields the JSON value at the path and
+// "->>" its text. Statements name such a field by its String form.
+type PathField struct {
+	Field string
+	Arrow string
+	Path  JSONPath
+}
+
+// String spells the path field the way the AST names it.
+func (f PathField) String() string {
+	return fmt.Sprintf("%s%s'%s'", f.Field, f.Arrow, strings.ReplaceAll(f.Path.Text, "'", "''"))
+}
+
+// ParsePathField reads a field name produced by PathField.String. ok is
+// false for plain field names.
+func ParsePathField(name string) (PathField, bool) {
+	i := strings.Index(name, "->")
+	if i < 0 {
+		return PathField{}, false
+	}
+	f := PathField{Field: name[:i], Arrow: "->"}
+	rest := name[i+2:]
+	if strings.HasPrefix(rest, ">") {
+		f.Arrow = "->>"
+		rest = rest[1:]
+	}
+	if len(rest) < 2 || rest[0] != '\'' || rest[len(rest)-1] != '\'' {
+		return PathField{}, false
+	}
+	path, err := ParseJSONPath(strings.ReplaceAll(rest[1:len(rest)-1], "''", "'"))
+	if err != nil {
+		return PathField{}, false
+	}
+	f.Path = path
+	return f, true
+}
+
+// JSONSet is JSON_SET(field, '$.path', value [, '$.path', value ...]) in
+// a SET list: the JSON document in field with the value at each path
+// replaced or added, in order.
+type JSONSet struct {
+	Field  string
+	Paths  []JSONPath
+	Values []Literal
+}
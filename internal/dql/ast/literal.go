@@ -0,0 +1,27 @@
+package ast
+
+// LiteralKind says how a value was written in a query.
+type LiteralKind int
+
+const (
+	LiteralString  LiteralKind = iota // 'text', "text" or a bare word
+	LiteralNumber                     // 42, -1.5, 1e3
+	LiteralBool                       // TRUE or FALSE
+	LiteralJSON                       // a bare {...} or [...]
+	LiteralNull                       // NULL
+	LiteralJSONSet                    // JSON_SET(...), in SET only
+)
+
+// Literal is a value in VALUES, SET or DEFAULT. Text is the value without
+// quotes or escapes; booleans are "true" or "false" and NULL has no text.
+// A JSON_SET call has no text either, its arguments are in Set.
+type Literal struct {
+	Kind LiteralKind
+	Text string
+	Set  *JSONSet
+}
+
+// IsNull reports whether the literal is NULL.
+func (l Literal) IsNull() bool {
+	return l.Kind == LiteralNull
+}
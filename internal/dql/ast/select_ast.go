@@ -9,11 +9,13 @@ type AggregateOrder struct {
 type SelectQueryAST struct {
 	Fields       []string
 	Table        string
-	Conditions   map[string]string
+	Graph        *GraphSource // Set when FROM names a graph traversal instead of a table
+	Where        LogicalNode  // WHERE tree, nil when absent
 	Aggregates   []AggregateFunc
 	GroupBy      []string
 	OrderBy      []OrderByField
 	OrderByAgg   []AggregateOrder // NEW: Aggregate ORDER BY
+	OrderByTopo  bool             // ORDER BY TOPOLOGICAL: execution order, adds the level column
 	Limit        int
 	IsCount      bool
 	HasCountStar bool
@@ -29,3 +31,15 @@ type OrderByField struct {
 	AggFunc string // e.g., SUM, COUNT
 	Desc    bool
 }
+
+// GraphSource replaces the table in SELECT ... FROM DESCENDANTS(...),
+// ANCESTORS(...) or PATH FROM ... TO ..., restricting the rows to tasks
+// reachable through the dependency graph.
+type GraphSource struct {
+	Kind     string // DESCENDANTS, ANCESTORS or PATH
+	TaskID   string // Start task
+	TargetID string // End task, PATH only
+	DAGID    string // IN DAG '...', empty to search every DAG
+	Table    string // IN TABLE t, the table holding the graph
+	MaxDepth int    // MAX DEPTH n, 0 for unlimited
+}
@@ -0,0 +1,30 @@
+package ast
+
+// ColumnDef is a user-defined column in CREATE TABLE or ALTER TABLE:
+// name TYPE [NOT NULL] [DEFAULT value]. Default is nil without DEFAULT.
+type ColumnDef struct {
+	Name    string
+	Type    string
+	NotNull bool
+	Default *string
+}
+
+// CreateTableAST represents CREATE TABLE name [(column, ...)]
+type CreateTableAST struct {
+	Name    string
+	Columns []ColumnDef
+}
+
+// DropTableAST represents DROP TABLE name
+type DropTableAST struct {
+	Name string
+}
+
+// AlterTableAST represents ALTER TABLE name ADD COLUMN ... or
+// ALTER TABLE name DROP COLUMN column. Action is "ADD" or "DROP"; Column
+// holds the new column for ADD and only its name for DROP.
+type AlterTableAST struct {
+	Name   string
+	Action string
+	Column ColumnDef
+}
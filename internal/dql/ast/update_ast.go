@@ -1,8 +1,17 @@
 package ast
 
 type UpdateQueryAST struct {
-	Table      string
-	SetFields  map[string]string
-	Conditions map[string]string
-	Where      map[string]string // ✅ Add WHERE conditions here
+	Table     string
+	SetFields []Assignment // In statement order, each field at most once
+	Where     LogicalNode  // WHERE tree, nil when absent
+	Returning []string     // RETURNING fields, nil when absent, ["*"] for all
+}
+
+// Assignment is one field = value of a SET list. In INSERT ... ON
+// CONFLICT DO UPDATE, Excluded names the field of the incoming row the
+// value is taken from (SET f = EXCLUDED.g) and Value is unused.
+type Assignment struct {
+	Field    string
+	Value    Literal
+	Excluded string
 }
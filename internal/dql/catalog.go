@@ -0,0 +1,131 @@
+package dql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// catalogFile is the name of the catalog inside the data root.
+const catalogFile = "catalog.json"
+
+// DatabaseInfo is the catalog entry of one database.
+type DatabaseInfo struct {
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	Owner     string            `json:"owner"`
+	Options   map[string]string `json:"options,omitempty"`
+	SizeBytes int64             `json:"size_bytes"`
+}
+
+// catalog is the persisted list of databases under a data root. It is not
+// safe for concurrent use on its own; the Manager serializes access.
+type catalog struct {
+	path      string
+	databases map[string]*DatabaseInfo
+}
+
+type catalogDocument struct {
+	Version   int             `json:"version"`
+	Databases []*DatabaseInfo `json:"databases"`
+}
+
+// loadCatalog reads the catalog of root and reconciles it with the
+// directories actually present: databases created before the catalog
+// existed are adopted, entries whose directory is gone are dropped.
+func loadCatalog(root string) (*catalog, error) {
+	c := &catalog{path: filepath.Join(root, catalogFile), databases: make(map[string]*DatabaseInfo)}
+
+	data, err := os.ReadFile(c.path)
+	switch {
+	case err == nil:
+		var doc catalogDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("❌ Corrupt catalog %s: %v", c.path, err)
+		}
+		for _, info := range doc.Databases {
+			c.databases[info.Name] = info
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("❌ Failed to read catalog: %v", err)
+	}
+
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to read DBs: %v", err)
+	}
+	present := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		present[entry.Name()] = true
+		if _, ok := c.databases[entry.Name()]; ok {
+			continue
+		}
+		info := &DatabaseInfo{Name: entry.Name()}
+		if fi, err := entry.Info(); err == nil {
+			info.CreatedAt = fi.ModTime().UTC()
+		}
+		c.databases[entry.Name()] = info
+	}
+	for name := range c.databases {
+		if !present[name] {
+			delete(c.databases, name)
+		}
+	}
+
+	if err := c.save(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// save writes the catalog atomically: to a temporary file first, which is
+// then renamed over the old catalog.
+func (c *catalog) save() error {
+	doc := catalogDocument{Version: 1, Databases: c.list()}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("❌ Failed to encode catalog: %v", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("❌ Failed to write catalog: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("❌ Failed to write catalog: %v", err)
+	}
+	return nil
+}
+
+// list returns the catalog entries sorted by name.
+func (c *catalog) list() []*DatabaseInfo {
+	infos := make([]*DatabaseInfo, 0, len(c.databases))
+	for _, info := range c.databases {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -2,114 +2,88 @@ package dql
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
-	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/executor"
 	"dagenie/internal/dql/parser"
+	"dagenie/internal/dql/storage"
 )
 
-var openDBs = make(map[string]*dagdb.DAGDB)
-
 // ---------------------- Dispatch Executor ----------------------
 
-func ExecuteDQLWithContext(db *dagdb.DAGDB, query string) (string, *dagdb.DAGDB, error) {
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return "", nil, fmt.Errorf("empty query")
-	}
-	lower := strings.ToLower(query)
-
-	switch {
-	// CREATE DATABASE
-	case strings.HasPrefix(lower, "create database"):
-		dbName := strings.TrimSpace(strings.TrimSuffix(query[len("create database"):], ";"))
-		if dbName == "" {
-			return "", nil, fmt.Errorf("❌ Invalid database name")
-		}
-		dbPath := filepath.Join("./data", dbName)
-		if _, err := os.Stat(dbPath); err == nil {
-			return "", nil, fmt.Errorf("❌ Database exists")
-		}
-
-		if err := os.MkdirAll(dbPath, 0755); err != nil {
-			return "", nil, fmt.Errorf("❌ Create DB failed: %v", err)
-		}
-		return fmt.Sprintf("✅ Database '%s' created", dbName), nil, nil
-
-		// USE DB
-		// USE DB
-	case strings.HasPrefix(lower, "use"):
-		dbName := strings.TrimSpace(query[4:]) // Use original case for name
-		if dbName == "" {
-			return "", nil, fmt.Errorf("❌ No database specified")
-		}
-		dbPath := "./data/" + dbName
-
-		// Check if directory exists
-		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-			return "", nil, fmt.Errorf("❌ Database '%s' does not exist", dbName)
-		}
+// ExecuteDQL dispatches raw query to parser → executor and returns the
+// structured result, timed. A write statement runs in a transaction of its
+// own.
+func ExecuteDQL(globalDB *storage.Database, queryLine string) (*executor.ResultSet, error) {
+	return ExecuteDQLInTransaction(globalDB, nil, queryLine)
+}
 
-		// Check if DB is already open
-		if existingDB, ok := openDBs[dbName]; ok {
-			return fmt.Sprintf("✅ Using database '%s'", dbName), existingDB, nil
-		}
+// ExecuteDQLInTransaction is ExecuteDQL with the writes of the statement
+// made in tx, when not nil. Table statements cannot run in a transaction.
+// A failed statement may have written part of its changes to tx, so the
+// caller should roll tx back to a savepoint taken before it.
+func ExecuteDQLInTransaction(globalDB *storage.Database, tx *executor.Transaction, queryLine string) (*executor.ResultSet, error) {
+	start := time.Now()
 
-		// Open new DB and cache it
-		newDB, err := dagdb.OpenDAGDB(dbPath)
-		if err != nil {
-			return "", nil, fmt.Errorf("❌ Failed to open DB '%s': %v", dbName, err)
+	if tx != nil {
+		if err := checkTransactional(queryLine, tableStatements); err != nil {
+			return nil, err
 		}
-		openDBs[dbName] = newDB // Cache it
+	}
 
-		return fmt.Sprintf("✅ Using database '%s'", dbName), newDB, nil
+	// Statements run under the database's statement lock; DROP TABLE and
+	// ALTER TABLE take it exclusively themselves and so wait for them.
+	if _, ok := matchStatement(queryLine, []string{"drop table", "alter table"}); !ok {
+		globalDB.RLock()
+		defer globalDB.RUnlock()
+	}
+	// Read-only statements also wait for a commit being applied, so they
+	// never see part of one
+	if _, ok := matchStatement(queryLine, readStatements); ok {
+		globalDB.RLockCommits()
+		defer globalDB.RUnlockCommits()
+	}
 
-	// SHOW DATABASES
-	case strings.HasPrefix(lower, "show databases"):
-		entries, err := os.ReadDir("./data")
-		if err != nil {
-			return "", nil, fmt.Errorf("❌ Failed to read DBs: %v", err)
-		}
-		var dbs []string
-		for _, entry := range entries {
-			fmt.Println(entry)
+	result, err := executeStatement(globalDB, tx, queryLine)
+	if result != nil {
+		result.Elapsed = time.Since(start)
+	}
+	return result, err
+}
 
-			if entry.IsDir() {
-				dbs = append(dbs, entry.Name())
-			}
-		}
+// tableStatements change the table catalog, which is not part of a
+// transaction.
+var tableStatements = []string{"create table", "drop table", "alter table"}
 
-		return fmt.Sprintf("%s %s", strings.Join(dbs, "\n"), "\n✅ Done"), nil, nil
+// readStatements only read tasks.
+var readStatements = []string{"select", "path", "critical", "show", "diff"}
 
-	// DROP DATABASE
-	case strings.HasPrefix(lower, "drop database"):
-		dbName := strings.TrimSpace(strings.TrimSuffix(query[len("drop database"):], ";"))
-		dbPath := filepath.Join("./data", dbName)
-		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
-			return "", nil, fmt.Errorf("❌ Database '%s' not found", dbName)
-		}
-		err := os.RemoveAll(dbPath)
-		if err != nil {
-			return "", nil, fmt.Errorf("❌ Delete failed: %v", err)
+// matchStatement returns the keywords of the given statements that a
+// query starts with, if any.
+func matchStatement(queryLine string, statements []string) (string, bool) {
+	words := strings.Join(strings.Fields(strings.ToLower(queryLine)), " ")
+	for _, kind := range statements {
+		if words == kind || strings.HasPrefix(words, kind+" ") {
+			return kind, true
 		}
-		return fmt.Sprintf("🗑️ Database '%s' deleted", dbName), nil, nil
+	}
+	return "", false
+}
 
-	// Pass to existing DQL (SELECT, INSERT, etc.)
-	default:
-		result, err := ExecuteDQL(db, query)
-		fmt.Println(result)
-		return result, nil, err
+// checkTransactional rejects a statement starting with one of the given
+// keywords inside a transaction.
+func checkTransactional(queryLine string, statements []string) error {
+	if kind, ok := matchStatement(queryLine, statements); ok {
+		return &QueryError{Code: CodeUnsupported, Err: fmt.Errorf("❌ %s is not allowed inside a transaction, COMMIT or ROLLBACK first", strings.ToUpper(kind))}
 	}
+	return nil
 }
 
-// ExecuteDQL dispatches raw query to parser → executor
-func ExecuteDQL(globalDB *dagdb.DAGDB, queryLine string) (string, error) {
+func executeStatement(globalDB *storage.Database, tx *executor.Transaction, queryLine string) (*executor.ResultSet, error) {
 	queryLine = strings.TrimSpace(queryLine)
 	if queryLine == "" {
-		return "", fmt.Errorf("empty query")
+		return nil, fmt.Errorf("empty query")
 	}
 
 	lowerQuery := strings.ToLower(queryLine)
@@ -118,48 +92,156 @@ func ExecuteDQL(globalDB *dagdb.DAGDB, queryLine string) (string, error) {
 	case strings.HasPrefix(lowerQuery, "select"):
 		astSelect, err := parser.ParseSelectToAST(queryLine)
 		if err != nil {
-			return "", fmt.Errorf("❌ SELECT Parse Error: %v", err)
+			return nil, parseError("SELECT", err)
+		}
+		result, err := executor.ExecuteSelect(globalDB, tx, astSelect)
+		if err != nil {
+			return nil, executionError("SELECT", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "path"):
+		astSelect, err := parser.ParsePathToAST(queryLine)
+		if err != nil {
+			return nil, parseError("PATH", err)
+		}
+		result, err := executor.ExecuteSelect(globalDB, tx, astSelect)
+		if err != nil {
+			return nil, executionError("PATH", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "critical"):
+		cpAST, err := parser.ParseCriticalPathToAST(queryLine)
+		if err != nil {
+			return nil, parseError("CRITICAL PATH", err)
 		}
-		result, err := executor.ExecuteSelect(globalDB, astSelect)
+		result, err := executor.ExecuteCriticalPath(globalDB, tx, cpAST)
 		if err != nil {
-			return "", fmt.Errorf("❌ SELECT Execution Error: %v", err)
+			return nil, executionError("CRITICAL PATH", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "add"), strings.HasPrefix(lowerQuery, "remove"):
+		depAST, err := parser.ParseDependencyToAST(queryLine)
+		if err != nil {
+			return nil, parseError("DEPENDENCY", err)
+		}
+		result, err := executor.ExecuteDependency(globalDB, tx, depAST)
+		if err != nil {
+			return nil, executionError("DEPENDENCY", err)
 		}
 		return result, nil
 
 	case strings.HasPrefix(lowerQuery, "insert"):
 		insertAST, err := parser.ParseInsertToAST(queryLine)
 		if err != nil {
-			return "", fmt.Errorf("❌ INSERT Parse Error: %v", err)
+			return nil, parseError("INSERT", err)
 		}
-		result, err := executor.ExecuteInsert(globalDB, insertAST)
+		result, err := executor.ExecuteInsert(globalDB, tx, insertAST)
 		if err != nil {
-			return "", fmt.Errorf("❌ INSERT Execution Error: %v", err)
+			return nil, executionError("INSERT", err)
 		}
 		return result, nil
 
 	case strings.HasPrefix(lowerQuery, "update"):
 		updateAST, err := parser.ParseUpdateToAST(queryLine)
 		if err != nil {
-			return "", fmt.Errorf("UPDATE Parse Error: %v", err)
+			return nil, parseError("UPDATE", err)
 		}
-		result, err := executor.ExecuteUpdate(globalDB, updateAST)
+		result, err := executor.ExecuteUpdate(globalDB, tx, updateAST)
 		if err != nil {
-			return "", fmt.Errorf("UPDATE Execution Error: %v", err)
+			return nil, executionError("UPDATE", err)
 		}
 		return result, nil
 
 	case strings.HasPrefix(lowerQuery, "delete"):
 		deleteAST, err := parser.ParseDeleteToAST(queryLine)
 		if err != nil {
-			return "", fmt.Errorf("❌ DELETE Parse Error: %v", err)
+			return nil, parseError("DELETE", err)
+		}
+		result, err := executor.ExecuteDelete(globalDB, tx, deleteAST)
+		if err != nil {
+			return nil, executionError("DELETE", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "create table"):
+		createAST, err := parser.ParseCreateTableToAST(queryLine)
+		if err != nil {
+			return nil, parseError("CREATE TABLE", err)
+		}
+		result, err := executor.ExecuteCreateTable(globalDB, createAST)
+		if err != nil {
+			return nil, executionError("CREATE TABLE", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "drop table"):
+		dropAST, err := parser.ParseDropTableToAST(queryLine)
+		if err != nil {
+			return nil, parseError("DROP TABLE", err)
+		}
+		result, err := executor.ExecuteDropTable(globalDB, dropAST)
+		if err != nil {
+			return nil, executionError("DROP TABLE", err)
 		}
-		result, err := executor.ExecuteDelete(globalDB, deleteAST)
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "alter table"):
+		alterAST, err := parser.ParseAlterTableToAST(queryLine)
+		if err != nil {
+			return nil, parseError("ALTER TABLE", err)
+		}
+		result, err := executor.ExecuteAlterTable(globalDB, alterAST)
+		if err != nil {
+			return nil, executionError("ALTER TABLE", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "copy dag"), strings.HasPrefix(lowerQuery, "rename dag"),
+		strings.HasPrefix(lowerQuery, "drop dag"), strings.HasPrefix(lowerQuery, "show dags"):
+		dagAST, err := parser.ParseDAGToAST(queryLine)
+		if err != nil {
+			return nil, parseError("DAG", err)
+		}
+		kind := dagAST.Action + " DAG"
+		var result *executor.ResultSet
+		switch dagAST.Action {
+		case "COPY":
+			result, err = executor.ExecuteCopyDAG(globalDB, tx, dagAST)
+		case "RENAME":
+			result, err = executor.ExecuteRenameDAG(globalDB, tx, dagAST)
+		case "DROP":
+			result, err = executor.ExecuteDropDAG(globalDB, tx, dagAST)
+		default:
+			kind = "SHOW DAGS"
+			result, err = executor.ExecuteShowDAGs(globalDB, tx, dagAST)
+		}
+		if err != nil {
+			return nil, executionError(kind, err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "diff dag"):
+		diffAST, err := parser.ParseDiffToAST(queryLine)
+		if err != nil {
+			return nil, parseError("DIFF DAG", err)
+		}
+		result, err := executor.ExecuteDiffDAG(globalDB, tx, diffAST)
+		if err != nil {
+			return nil, executionError("DIFF DAG", err)
+		}
+		return result, nil
+
+	case strings.HasPrefix(lowerQuery, "show tables"):
+		result, err := executor.ExecuteShowTables(globalDB, tx)
 		if err != nil {
-			return "", fmt.Errorf("❌ DELETE Execution Error: %v", err)
+			return nil, executionError("SHOW TABLES", err)
 		}
 		return result, nil
 
 	default:
-		return "", fmt.Errorf("❌ Unsupported query type: %s", strings.Split(queryLine, " ")[0])
+		return nil, &QueryError{Code: CodeUnsupported, Err: fmt.Errorf("❌ Unsupported query type: %s", strings.Split(queryLine, " ")[0])}
 	}
 }
@@ -0,0 +1,60 @@
+package dql
+
+import (
+	"errors"
+	"fmt"
+
+	"dagenie/internal/dql/storage"
+)
+
+// Error codes reported to clients alongside failed statements.
+const (
+	CodeParse       = "PARSE_ERROR"
+	CodeExecution   = "EXECUTION_ERROR"
+	CodeUnsupported = "UNSUPPORTED"
+	CodeConflict    = "CONFLICT"
+	CodeTxAborted   = "TX_ABORTED"
+)
+
+// QueryError attaches an error code to a failed statement.
+type QueryError struct {
+	Code string
+	Err  error
+}
+
+func (e *QueryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the code of a statement error, defaulting to
+// CodeExecution for errors that carry none.
+func ErrorCode(err error) string {
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		return qe.Code
+	}
+	return CodeExecution
+}
+
+// IsRetryable reports whether a failed statement may succeed when sent
+// again, as is the case for write conflicts outside a transaction. A
+// statement of an aborted transaction is not: the client has to start
+// over from BEGIN.
+func IsRetryable(err error) bool {
+	return ErrorCode(err) == CodeConflict
+}
+
+func parseError(kind string, err error) error {
+	return &QueryError{Code: CodeParse, Err: fmt.Errorf("❌ %s Parse Error: %w", kind, err)}
+}
+
+func executionError(kind string, err error) error {
+	if errors.Is(err, storage.ErrWriteConflict) {
+		return &QueryError{Code: CodeConflict, Err: fmt.Errorf("❌ %s Execution Error: %w", kind, err)}
+	}
+	return &QueryError{Code: CodeExecution, Err: fmt.Errorf("❌ %s Execution Error: %w", kind, err)}
+}
@@ -3,19 +3,140 @@ package executor
 import (
 	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Entry point for evaluating logical tree on a task
-func evaluateConditionTree(task dagdb.DAGTask, node ast.LogicalNode) bool {
-	if node == nil {
+// validFields are the task fields that can be selected or filtered on.
+var validFields = map[string]bool{
+	"_id": true, "dagid": true, "id": true, "name": true,
+	"status": true, "payload": true, "dependencies": true,
+	"duration": true, "retries": true, "dependency_count": true,
+}
+
+// validateWhere rejects WHERE trees that reference unknown fields or
+// compare typed fields with values of another type, which would otherwise
+// silently match nothing.
+func (s *tableSchema) validateWhere(node ast.LogicalNode) error {
+	switch n := node.(type) {
+	case *ast.AndNode:
+		if err := s.validateWhere(n.Left); err != nil {
+			return err
+		}
+		return s.validateWhere(n.Right)
+	case *ast.OrNode:
+		if err := s.validateWhere(n.Left); err != nil {
+			return err
+		}
+		return s.validateWhere(n.Right)
+	case *ast.NotNode:
+		return s.validateWhere(n.Expr)
+	case *ast.ConditionNode:
+		if !s.validField(n.Field) {
+			return fmt.Errorf("❌ Unknown field in WHERE: %s", n.Field)
+		}
+		if strings.Contains(n.Operator, "LIKE") || strings.Contains(n.Operator, "CONTAINS") {
+			return nil
+		}
+		literals := n.Values
+		if n.Value != "" {
+			literals = append([]string{n.Value}, literals...)
+		}
+		if def, ok := s.column(n.Field); ok {
+			if def.Type == storage.ColumnString || def.Type == storage.ColumnJSON {
+				return nil
+			}
+			for _, lit := range literals {
+				if _, err := encodeColumnValue(def, lit); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if strings.EqualFold(n.Field, "dependencies") {
+			for _, lit := range literals {
+				var list []string
+				if err := json.Unmarshal([]byte(lit), &list); err != nil {
+					return fmt.Errorf("❌ Invalid value '%s' for dependencies: expected a JSON list of IDs, or use CONTAINS", lit)
+				}
+			}
+		}
+		if isNumericField(n.Field) {
+			for _, lit := range literals {
+				if _, err := strconv.ParseFloat(lit, 64); err != nil {
+					return fmt.Errorf("❌ Invalid numeric value '%s' for %s", lit, n.Field)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateConditionTree is the single WHERE evaluator shared by SELECT,
+// UPDATE and DELETE. A nil tree matches every task.
+func (s *tableSchema) evaluateConditionTree(task dagdb.DAGTask, node ast.LogicalNode) bool {
+	switch n := node.(type) {
+	case nil:
 		return true // No condition
+	case *ast.AndNode:
+		return s.evaluateConditionTree(task, n.Left) && s.evaluateConditionTree(task, n.Right)
+	case *ast.OrNode:
+		return s.evaluateConditionTree(task, n.Left) || s.evaluateConditionTree(task, n.Right)
+	case *ast.NotNode:
+		return !s.evaluateConditionTree(task, n.Expr)
+	case *ast.ConditionNode:
+		return s.evaluateCondition(task, n)
+	default:
+		return false
+	}
+}
+
+// filterTasks returns the tasks matching the WHERE tree.
+func (s *tableSchema) filterTasks(tasks []dagdb.DAGTask, where ast.LogicalNode) []dagdb.DAGTask {
+	var filtered []dagdb.DAGTask
+	for _, task := range tasks {
+		if s.evaluateConditionTree(task, where) {
+			filtered = append(filtered, task)
+		}
+	}
+	return filtered
+}
+
+// loadCandidateTasks loads the tasks a WHERE tree can possibly match,
+// narrowing the scan by _id or dagid when the tree requires an exact value
+// for one of them. The result still has to be filtered.
+func loadCandidateTasks(db *storage.Table, where ast.LogicalNode) ([]dagdb.DAGTask, error) {
+	if objectID, ok := requiredEquality(where, "_id"); ok {
+		return db.QueryByObjectID(objectID)
+	}
+	if dagID, ok := requiredEquality(where, "dagid"); ok {
+		return db.ListTasksByDAG(dagID)
+	}
+	return db.ListAllTasks()
+}
+
+// requiredEquality finds a "field = value" condition that every matching
+// task must satisfy, i.e. one reachable from the root through AND nodes only.
+func requiredEquality(node ast.LogicalNode, field string) (string, bool) {
+	switch n := node.(type) {
+	case *ast.AndNode:
+		if v, ok := requiredEquality(n.Left, field); ok {
+			return v, true
+		}
+		return requiredEquality(n.Right, field)
+	case *ast.ConditionNode:
+		if n.Operator == "=" && strings.EqualFold(n.Field, field) {
+			return n.Value, true
+		}
 	}
-	return node.Evaluate(task)
+	return "", false
 }
 
+// getField renders a built-in task field as text.
 func getField(task dagdb.DAGTask, field string) string {
 	switch strings.ToLower(field) {
 	case "id":
@@ -34,68 +155,291 @@ func getField(task dagdb.DAGTask, field string) string {
 		return fmt.Sprintf("%d", task.Duration)
 	case "retries":
 		return fmt.Sprintf("%d", task.Retries)
+	case "dependencies":
+		deps := task.Dependencies
+		if deps == nil {
+			deps = []string{}
+		}
+		encoded, _ := json.Marshal(deps)
+		return string(encoded)
+	case "dependency_count":
+		return fmt.Sprintf("%d", len(task.Dependencies))
 	default:
 		return ""
 	}
 }
 
-func evaluateCondition(task dagdb.DAGTask, cond *ast.ConditionNode) bool {
-	field := strings.ToLower(cond.Field)
-	value := cond.Value
-
-	switch field {
-	case "dagid":
-		return task.DAGID == value
-	case "id":
-		return task.ID == value
-	case "name":
-		return task.Name == value
-	case "status":
-		return task.Status == value
-	case "payload":
-		return task.Payload == value
+// fieldValue returns the typed value of a task field: a string for text
+// fields, an int for duration, retries and dependency_count, a []string
+// for dependencies, the decoded value of a user-defined column, and for a
+// JSON path expression the value at the path, see pathValue.
+func (s *tableSchema) fieldValue(task dagdb.DAGTask, field string) interface{} {
+	if pf, ok := s.pathField(field); ok {
+		return s.pathValue(task, pf)
+	}
+	if def, ok := s.column(field); ok {
+		return s.columnValue(task, def)
+	}
+	switch strings.ToLower(field) {
 	case "duration":
-		dur, err := strconv.Atoi(value)
+		return task.Duration
+	case "retries":
+		return task.Retries
+	case "dependencies":
+		return task.Dependencies
+	case "dependency_count":
+		return len(task.Dependencies)
+	default:
+		return getField(task, field)
+	}
+}
+
+// getField renders a task field, built-in or user-defined, or a JSON path
+// expression as text.
+func (s *tableSchema) getField(task dagdb.DAGTask, field string) string {
+	if pf, ok := s.pathField(field); ok {
+		return columnText(s.pathValue(task, pf))
+	}
+	if def, ok := s.column(field); ok {
+		return columnText(s.columnValue(task, def))
+	}
+	return getField(task, field)
+}
+
+// isNumericField reports whether a built-in field holds integers.
+func isNumericField(field string) bool {
+	switch strings.ToLower(field) {
+	case "duration", "retries", "dependency_count":
+		return true
+	}
+	return false
+}
+
+// compareToLiteral compares a typed field value with a literal from the
+// query. Numbers compare numerically, timestamps chronologically, booleans
+// false before true, strings exactly, byte by byte (only ILIKE ignores
+// case), and dependencies by their canonical JSON, so '["a", "b"]'
+// matches a task depending on a then b. ok is false when the two cannot
+// be compared.
+func compareToLiteral(value interface{}, literal string) (cmp int, ok bool) {
+	switch v := value.(type) {
+	case int, float64:
+		n, err := strconv.ParseFloat(literal, 64)
 		if err != nil {
-			return false
+			return 0, false
 		}
-		return task.Duration == dur
-	case "retries":
-		retries, err := strconv.Atoi(value)
+		f, _ := numericValue(v)
+		return compareFloats(f, n), true
+	case bool:
+		b, err := strconv.ParseBool(literal)
 		if err != nil {
-			return false
+			return 0, false
 		}
-		return task.Retries == retries
-	case "_id":
-		return task.ObjectID == value
+		return compareBools(v, b), true
+	case time.Time:
+		t, ok := parseTimestamp(literal)
+		if !ok {
+			return 0, false
+		}
+		return v.Compare(t), true
+	case json.RawMessage:
+		return strings.Compare(string(v), literal), true
+	case string:
+		return strings.Compare(v, literal), true
+	case []string:
+		var want []string
+		if err := json.Unmarshal([]byte(literal), &want); err != nil {
+			return 0, false
+		}
+		return strings.Compare(canonicalList(v), canonicalList(want)), true
+	default:
+		return 0, false
+	}
+}
+
+// canonicalList encodes a list of strings as compact JSON, with no list
+// encoded as [].
+func canonicalList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	encoded, _ := json.Marshal(list)
+	return string(encoded)
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// compareTasks orders two tasks by a field using its natural type. Tasks
+// without a value for a user-defined column sort first.
+func (s *tableSchema) compareTasks(a, b dagdb.DAGTask, field string) int {
+	va, vb := s.fieldValue(a, field), s.fieldValue(b, field)
+	switch {
+	case va == nil && vb == nil:
+		return 0
+	case va == nil:
+		return -1
+	case vb == nil:
+		return 1
+	}
+	if x, ok := numericValue(va); ok {
+		if y, ok := numericValue(vb); ok {
+			return compareFloats(x, y)
+		}
+	}
+	if x, ok := va.(time.Time); ok {
+		if y, ok := vb.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	if x, ok := va.(bool); ok {
+		if y, ok := vb.(bool); ok {
+			return compareBools(x, y)
+		}
+	}
+	return strings.Compare(s.getField(a, field), s.getField(b, field))
+}
+
+// isNullValue treats missing column values, empty strings and empty
+// dependency lists as NULL. Numeric fields always hold a value.
+func isNullValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []string:
+		return len(v) == 0
 	default:
 		return false
 	}
 }
 
-func evaluateSingleCondition(task dagdb.DAGTask, cond *ast.ConditionNode) bool {
-	val := strings.ToLower(cond.Value)
+// evaluateCondition checks a single condition against a task's typed
+// field value.
+func (s *tableSchema) evaluateCondition(task dagdb.DAGTask, cond *ast.ConditionNode) bool {
+	value := s.fieldValue(task, cond.Field)
 
-	switch cond.Field {
-	case "dagid":
-		return strings.ToLower(task.DAGID) == val
-	case "id":
-		return strings.ToLower(task.ID) == val
-	case "name":
-		return strings.ToLower(task.Name) == val
-	case "status":
-		return strings.ToLower(task.Status) == val
-	case "payload":
-		return strings.ToLower(task.Payload) == val
-	case "duration":
-		intVal, _ := strconv.Atoi(val)
-		return task.Duration == intVal
-	case "retries":
-		intVal, _ := strconv.Atoi(val)
-		return task.Retries == intVal
-	case "_id":
-		return strings.ToLower(task.ObjectID) == val
+	switch cond.Operator {
+	case "IS NULL":
+		return isNullValue(value)
+	case "IS NOT NULL":
+		return !isNullValue(value)
+	case "IN", "NOT IN":
+		found := false
+		for _, want := range cond.Values {
+			if cmp, ok := compareToLiteral(value, want); ok && cmp == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (cond.Operator == "IN")
+	case "BETWEEN", "NOT BETWEEN":
+		low, okLow := compareToLiteral(value, cond.Values[0])
+		high, okHigh := compareToLiteral(value, cond.Values[1])
+		if !okLow || !okHigh {
+			return false
+		}
+		return (low >= 0 && high <= 0) == (cond.Operator == "BETWEEN")
+	case "CONTAINS", "NOT CONTAINS":
+		return containsValue(value, cond.Value) == (cond.Operator == "CONTAINS")
+	case "LIKE", "NOT LIKE":
+		return likeMatch(columnText(value), cond.Value, false) == (cond.Operator == "LIKE")
+	case "ILIKE", "NOT ILIKE":
+		return likeMatch(columnText(value), cond.Value, true) == (cond.Operator == "ILIKE")
+	}
+
+	cmp, ok := compareToLiteral(value, cond.Value)
+	if !ok {
+		return false
+	}
+	switch cond.Operator {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
 	default:
 		return false
 	}
 }
+
+// containsValue checks list membership for dependencies and a substring
+// match for text fields, both case-sensitive.
+func containsValue(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case []string:
+		for _, item := range v {
+			if item == want {
+				return true
+			}
+		}
+		return false
+	case string:
+		return strings.Contains(v, want)
+	case json.RawMessage:
+		return strings.Contains(string(v), want)
+	default:
+		return false
+	}
+}
+
+// likeMatch matches s against a SQL LIKE pattern where % matches any run of
+// characters and _ matches exactly one.
+func likeMatch(s, pattern string, foldCase bool) bool {
+	if foldCase {
+		s = strings.ToLower(s)
+		pattern = strings.ToLower(pattern)
+	}
+	str, pat := []rune(s), []rune(pattern)
+
+	// Greedy match with backtracking to the most recent %.
+	si, pi := 0, 0
+	starPi, starSi := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(pat) && (pat[pi] == '_' || pat[pi] == str[si]):
+			si++
+			pi++
+		case pi < len(pat) && pat[pi] == '%':
+			starPi, starSi = pi, si
+			pi++
+		case starPi != -1:
+			starSi++
+			si = starSi
+			pi = starPi + 1
+		default:
+			return false
+		}
+	}
+	for pi < len(pat) && pat[pi] == '%' {
+		pi++
+	}
+	return pi == len(pat)
+}
@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"fmt"
+	"strings"
+)
+
+// TaskSchedule holds the critical path method figures of one task, in
+// duration units from the start of the DAG.
+type TaskSchedule struct {
+	Task           dagdb.DAGTask
+	EarliestStart  int
+	EarliestFinish int
+	LatestStart    int
+	LatestFinish   int
+	Slack          int
+	Critical       bool
+}
+
+// CriticalPathResult is the outcome of a critical path analysis.
+type CriticalPathResult struct {
+	DAGID         string
+	TotalDuration int
+	Path          []dagdb.DAGTask // Tasks on the critical path, in execution order
+	Schedule      []TaskSchedule  // Every task, in topological order
+}
+
+// CriticalPath computes the longest duration-weighted path through a DAG
+// together with the earliest/latest start and slack of every task.
+func CriticalPath(db *storage.Table, dagID string) (*CriticalPathResult, error) {
+	tasks, err := db.ListTasksByDAG(dagID)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("❌ DAG '%s' has no tasks", dagID)
+	}
+
+	g := newTaskGraph(tasks)
+	order, _, err := g.topoLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	// Forward pass: earliest start is the latest finish of any dependency.
+	es := make(map[string]int, len(order))
+	ef := make(map[string]int, len(order))
+	total := 0
+	for _, id := range order {
+		for _, dep := range g.dependencies(id) {
+			if ef[dep] > es[id] {
+				es[id] = ef[dep]
+			}
+		}
+		ef[id] = es[id] + g.tasks[id].Duration
+		if ef[id] > total {
+			total = ef[id]
+		}
+	}
+
+	// Backward pass: latest finish is the earliest latest-start of any dependent.
+	ls := make(map[string]int, len(order))
+	lf := make(map[string]int, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		lf[id] = total
+		for _, n := range g.dependents[id] {
+			if _, ok := g.tasks[n]; ok && ls[n] < lf[id] {
+				lf[id] = ls[n]
+			}
+		}
+		ls[id] = lf[id] - g.tasks[id].Duration
+	}
+
+	result := &CriticalPathResult{DAGID: dagID, TotalDuration: total}
+	for _, id := range order {
+		result.Schedule = append(result.Schedule, TaskSchedule{
+			Task:           g.tasks[id],
+			EarliestStart:  es[id],
+			EarliestFinish: ef[id],
+			LatestStart:    ls[id],
+			LatestFinish:   lf[id],
+			Slack:          ls[id] - es[id],
+			Critical:       ls[id] == es[id],
+		})
+	}
+
+	// Walk back from a critical task finishing last through critical
+	// dependencies that finish exactly when the current task starts.
+	cur := ""
+	for _, id := range order {
+		if ef[id] == total && ls[id] == es[id] {
+			cur = id
+			break
+		}
+	}
+	var path []string
+	for cur != "" {
+		path = append([]string{cur}, path...)
+		prev := ""
+		for _, dep := range g.dependencies(cur) {
+			if ef[dep] == es[cur] && ls[dep] == es[dep] {
+				prev = dep
+				break
+			}
+		}
+		cur = prev
+	}
+	result.Path = g.tasksFor(path)
+
+	return result, nil
+}
+
+// ExecuteCriticalPath runs a CRITICAL PATH OF DAG query. It returns the
+// schedule of every task in topological order; path_position numbers the
+// tasks of the critical path in execution order and is NULL for the rest,
+// and the earliest_finish of the last one is the total duration.
+func ExecuteCriticalPath(database *storage.Database, tx *Transaction, cpAST *ast.CriticalPathQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, cpAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	result, err := CriticalPath(db, cpAST.DAGID)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &ResultSet{Columns: []Column{
+		{Name: "id", Type: TypeString},
+		{Name: "name", Type: TypeString},
+		{Name: "duration", Type: TypeInt},
+		{Name: "earliest_start", Type: TypeInt},
+		{Name: "earliest_finish", Type: TypeInt},
+		{Name: "latest_start", Type: TypeInt},
+		{Name: "slack", Type: TypeInt},
+		{Name: "critical", Type: TypeBool},
+		{Name: "path_position", Type: TypeInt},
+	}}
+	positions := make(map[string]int, len(result.Path))
+	ids := make([]string, 0, len(result.Path))
+	for i, task := range result.Path {
+		positions[task.ID] = i + 1
+		ids = append(ids, task.ID)
+	}
+	for _, s := range result.Schedule {
+		var position interface{}
+		if p, ok := positions[s.Task.ID]; ok {
+			position = p
+		}
+		rs.Rows = append(rs.Rows, []interface{}{
+			s.Task.ID, s.Task.Name, s.Task.Duration, s.EarliestStart, s.EarliestFinish, s.LatestStart, s.Slack, s.Critical, position,
+		})
+	}
+
+	rs.Message = fmt.Sprintf("🛤️ Critical path: %s\n⏱️ Total duration=%d", strings.Join(ids, " -> "), result.TotalDuration)
+	return rs, nil
+}
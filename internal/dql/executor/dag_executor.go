@@ -0,0 +1,164 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"dagenie/utils"
+	"fmt"
+	"sort"
+)
+
+// ExecuteCopyDAG copies every task of a DAG into a new DAG, with new
+// ObjectIDs and the same dependencies, in tx, or in a transaction of its
+// own when tx is nil.
+func ExecuteCopyDAG(database *storage.Database, tx *Transaction, dagAST *ast.DAGQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		tasks, schema, err := loadDAGTransfer(database, tx, dagAST)
+		if err != nil {
+			return nil, err
+		}
+		for _, old := range tasks {
+			task := old
+			task.ObjectID = utils.GenerateObjectID()
+			task.DAGID = dagAST.Target
+			schema.setColumnValues(task, schema.columnValues(old))
+			tx.writes.insert(schema, task)
+		}
+		return statusResult(len(tasks), "✅ Copied DAG '%s' to '%s' (%d task(s))", dagAST.DAGID, dagAST.Target, len(tasks)), nil
+	})
+}
+
+// ExecuteRenameDAG moves every task of a DAG to a new DAG ID, keeping
+// their ObjectIDs, in tx, or in a transaction of its own when tx is nil.
+func ExecuteRenameDAG(database *storage.Database, tx *Transaction, dagAST *ast.DAGQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		tasks, schema, err := loadDAGTransfer(database, tx, dagAST)
+		if err != nil {
+			return nil, err
+		}
+		for _, old := range tasks {
+			task := old
+			task.DAGID = dagAST.Target
+			tx.writes.rekey(schema, old, task)
+		}
+		return statusResult(len(tasks), "✅ Renamed DAG '%s' to '%s' (%d task(s))", dagAST.DAGID, dagAST.Target, len(tasks)), nil
+	})
+}
+
+// ExecuteDropDAG deletes every task of a DAG, in tx, or in a transaction
+// of its own when tx is nil.
+func ExecuteDropDAG(database *storage.Database, tx *Transaction, dagAST *ast.DAGQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		tasks, err := loadDAG(database, tx, dagAST.Table, dagAST.DAGID)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := loadSchema(database, tx, dagAST.Table)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			tx.writes.delete(schema, task)
+		}
+		return statusResult(len(tasks), "🗑️ DAG '%s' dropped (%d task(s))", dagAST.DAGID, len(tasks)), nil
+	})
+}
+
+// LoadDAG returns the committed tasks of a DAG in a table, which must have
+// some.
+func LoadDAG(database *storage.Database, table, dagID string) ([]dagdb.DAGTask, error) {
+	return loadDAG(database, nil, table, dagID)
+}
+
+// loadDAG is LoadDAG as a statement in tx sees the table.
+func loadDAG(database *storage.Database, tx *Transaction, table, dagID string) ([]dagdb.DAGTask, error) {
+	db, err := openTable(database, tx, table)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := db.ListTasksByDAG(dagID)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("❌ DAG '%s' not found", dagID)
+	}
+	return tasks, nil
+}
+
+// loadDAGTransfer returns the tasks of the source DAG of a COPY or RENAME,
+// with the schema of the table, and checks that the target DAG does not
+// exist yet.
+func loadDAGTransfer(database *storage.Database, tx *Transaction, dagAST *ast.DAGQueryAST) ([]dagdb.DAGTask, *tableSchema, error) {
+	if dagAST.Target == dagAST.DAGID {
+		return nil, nil, fmt.Errorf("❌ DAG '%s' cannot be copied or renamed to itself", dagAST.DAGID)
+	}
+	tasks, err := loadDAG(database, tx, dagAST.Table, dagAST.DAGID)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := openTable(database, tx, dagAST.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing, err := db.ListTasksByDAG(dagAST.Target)
+	if err != nil {
+		return nil, nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+	if len(existing) > 0 {
+		return nil, nil, fmt.Errorf("❌ DAG '%s' already exists", dagAST.Target)
+	}
+	schema, err := loadSchema(database, tx, dagAST.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, schema, nil
+}
+
+// ExecuteShowDAGs lists the DAGs of a table with their task count, the
+// number of roots (tasks without dependencies) and leaves (tasks nothing
+// depends on), and the total duration of their tasks.
+func ExecuteShowDAGs(database *storage.Database, tx *Transaction, dagAST *ast.DAGQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, dagAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := db.ListAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+
+	byDAG := make(map[string][]dagdb.DAGTask)
+	for _, task := range tasks {
+		byDAG[task.DAGID] = append(byDAG[task.DAGID], task)
+	}
+	dagIDs := make([]string, 0, len(byDAG))
+	for dagID := range byDAG {
+		dagIDs = append(dagIDs, dagID)
+	}
+	sort.Strings(dagIDs)
+
+	result := &ResultSet{Columns: []Column{
+		{Name: "dagid", Type: TypeString},
+		{Name: "tasks", Type: TypeInt},
+		{Name: "roots", Type: TypeInt},
+		{Name: "leaves", Type: TypeInt},
+		{Name: "total_duration", Type: TypeInt},
+	}}
+	for _, dagID := range dagIDs {
+		g := newTaskGraph(byDAG[dagID])
+		roots, leaves, duration := 0, 0, 0
+		for _, id := range g.ids {
+			if len(g.dependencies(id)) == 0 {
+				roots++
+			}
+			if len(g.dependents[id]) == 0 {
+				leaves++
+			}
+			duration += g.tasks[id].Duration
+		}
+		result.Rows = append(result.Rows, []interface{}{dagID, len(g.ids), roots, leaves, duration})
+	}
+	return result, nil
+}
@@ -3,74 +3,193 @@ package executor
 import (
 	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
 	"fmt"
+	"sort"
+	"strings"
 )
 
-// ExecuteDelete deletes tasks matching WHERE conditions.
-func ExecuteDelete(db *dagdb.DAGDB, deleteAST *ast.DeleteQueryAST) (string, error) {
-	if deleteAST.Table != "dag" {
-		return "", fmt.Errorf("unsupported table: %s", deleteAST.Table)
+// ExecuteDelete deletes tasks matching WHERE conditions, in tx, or in a
+// transaction of its own when tx is nil. Either every affected task is
+// written or, if one write fails, none is.
+//
+// A task other tasks depend on is only deleted along with them, unless
+// the statement says what to do with them: CASCADE deletes every task
+// that directly or transitively depends on a deleted one, DETACH removes
+// the deleted tasks from the dependencies of the remaining ones. The
+// result of CASCADE and DETACH lists every affected task. With RETURNING,
+// the result has the listed fields of every deleted task instead, as it
+// was; tasks DETACH only modified are counted in RowsAffected but not
+// returned.
+func ExecuteDelete(database *storage.Database, tx *Transaction, deleteAST *ast.DeleteQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		return executeDelete(database, tx, deleteAST)
+	})
+}
+
+func executeDelete(database *storage.Database, tx *Transaction, deleteAST *ast.DeleteQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, deleteAST.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := loadSchema(database, tx, deleteAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.validateWhere(deleteAST.Where); err != nil {
+		return nil, err
+	}
+	returning, err := schema.returningFields(deleteAST.Returning)
+	if err != nil {
+		return nil, err
 	}
 
 	// 1. Load candidate tasks
-	var tasks []dagdb.DAGTask
-	var err error
-
-	if objectID, ok := deleteAST.Conditions["_id"]; ok {
-		tasks, err = db.QueryByObjectID(objectID)
-	} else if dagID, ok := deleteAST.Conditions["dagid"]; ok {
-		tasks, err = db.ListTasksByDAG(dagID)
-	} else {
-		tasks, err = db.ListAllTasks()
+	tasks, err := loadCandidateTasks(db, deleteAST.Where)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+
+	// 2. Filter tasks by WHERE conditions and work out what happens to
+	// their dependents
+	matched := schema.filterTasks(tasks, deleteAST.Where)
+	if len(matched) == 0 {
+		return schema.withReturning(statusResult(0, "❌ No tasks matched for deletion"), returning, nil), nil
 	}
+	plan, err := planDelete(db, matched, deleteAST.Mode)
 	if err != nil {
-		return "", fmt.Errorf("❌ Task fetch error: %v", err)
+		return nil, err
 	}
 
-	// 2. Filter tasks by WHERE conditions
-	var deletedCount int
-	for _, task := range tasks {
-		match := true
-		for field, expected := range deleteAST.Conditions {
-			switch field {
-			case "dagid":
-				if task.DAGID != expected {
-					match = false
-				}
-			case "id":
-				if task.ID != expected {
-					match = false
-				}
-			case "status":
-				if task.Status != expected {
-					match = false
+	// 3. Record the writes of the plan; they are applied on commit
+	for _, change := range plan.detached {
+		tx.writes.update(schema, change.old, change.new)
+	}
+	var deleted []dagdb.DAGTask
+	for _, step := range plan.deleted {
+		task := step.task
+		tx.writes.delete(schema, task)
+		deleted = append(deleted, task)
+	}
+
+	result := statusResult(len(deleted)+len(plan.detached), "✅ Deleted %d task(s)", len(deleted))
+	switch deleteAST.Mode {
+	case "CASCADE":
+		result.Message += fmt.Sprintf(", %d of them by CASCADE", len(deleted)-len(matched))
+	case "DETACH":
+		result.Message += fmt.Sprintf(", detached %d dependent task(s)", len(plan.detached))
+	}
+	if deleteAST.Mode != "" && returning == nil {
+		result.Columns = []Column{
+			{Name: "dagid", Type: TypeString},
+			{Name: "id", Type: TypeString},
+			{Name: "_id", Type: TypeString},
+			{Name: "action", Type: TypeString},
+		}
+		for _, step := range plan.deleted {
+			result.Rows = append(result.Rows, []interface{}{step.task.DAGID, step.task.ID, step.task.ObjectID, step.action})
+		}
+		for _, change := range plan.detached {
+			result.Rows = append(result.Rows, []interface{}{change.new.DAGID, change.new.ID, change.new.ObjectID, "detached"})
+		}
+	}
+	return schema.withReturning(result, returning, deleted), nil
+}
+
+// deletePlan is what a DELETE writes: the tasks it deletes, each with
+// "deleted" if it matched WHERE or "cascaded" if it goes with one, and
+// for DETACH the dependents that lose dependencies.
+type deletePlan struct {
+	deleted  []deleteStep
+	detached []taskChange
+}
+
+type deleteStep struct {
+	task   dagdb.DAGTask
+	action string
+}
+
+// taskChange is a task before and after an update.
+type taskChange struct {
+	old, new dagdb.DAGTask
+}
+
+// planDelete works out, DAG by DAG, what deleting the matched tasks does
+// to the tasks depending on them. Without a mode it fails if any task
+// outside the matched ones depends on one of them.
+func planDelete(db *storage.Table, matched []dagdb.DAGTask, mode string) (*deletePlan, error) {
+	byDAG := make(map[string][]dagdb.DAGTask)
+	var dagIDs []string
+	for _, task := range matched {
+		if _, ok := byDAG[task.DAGID]; !ok {
+			dagIDs = append(dagIDs, task.DAGID)
+		}
+		byDAG[task.DAGID] = append(byDAG[task.DAGID], task)
+	}
+	sort.Strings(dagIDs)
+
+	plan := &deletePlan{}
+	for _, dagID := range dagIDs {
+		current, err := db.ListTasksByDAG(dagID)
+		if err != nil {
+			return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		g := newTaskGraph(current)
+
+		removed := make(map[string]bool)
+		for _, task := range byDAG[dagID] {
+			removed[task.ID] = true
+			plan.deleted = append(plan.deleted, deleteStep{task: task, action: "deleted"})
+		}
+
+		for _, task := range byDAG[dagID] {
+			var dependents []string
+			for _, id := range g.dependents[task.ID] {
+				if _, ok := g.tasks[id]; ok && !removed[id] {
+					dependents = append(dependents, id)
 				}
-			case "_id":
-				if task.ObjectID != expected {
-					match = false
+			}
+			if len(dependents) == 0 {
+				continue
+			}
+			switch mode {
+			case "CASCADE":
+				for _, id := range g.descendants(task.ID, 0) {
+					if !removed[id] {
+						removed[id] = true
+						plan.deleted = append(plan.deleted, deleteStep{task: g.tasks[id], action: "cascaded"})
+					}
 				}
+			case "DETACH":
+				// The dependents are stripped below, once every
+				// deleted task of the DAG is known
 			default:
-				match = false
-			}
-			if !match {
-				break
+				return nil, fmt.Errorf("❌ Cannot delete task '%s' in DAG '%s': task(s) '%s' depend on it, use CASCADE or DETACH", task.ID, dagID, strings.Join(dependents, "', '"))
 			}
 		}
 
-		if match {
-			err := db.DeleteTask(task.DAGID, task.ID)
-			if err != nil {
-				fmt.Printf("❌ Failed to delete task: ID=%s, DAGID=%s: %v\n", task.ID, task.DAGID, err)
-			} else {
-				fmt.Printf("🗑️ Deleted: ID=%s DAGID=%s\n", task.ID, task.DAGID)
-				deletedCount++
+		if mode != "DETACH" {
+			continue
+		}
+		for _, id := range g.ids {
+			old := g.tasks[id]
+			if removed[id] {
+				continue
 			}
+			deps := []string{}
+			for _, dep := range old.Dependencies {
+				if !removed[dep] {
+					deps = append(deps, dep)
+				}
+			}
+			if len(deps) == len(old.Dependencies) {
+				continue
+			}
+			task := old
+			task.Dependencies = deps
+			plan.detached = append(plan.detached, taskChange{old: old, new: task})
 		}
 	}
-
-	if deletedCount == 0 {
-		return "❌ No tasks matched for deletion", nil
-	}
-
-	return fmt.Sprintf("✅ Deleted %d task(s)", deletedCount), nil
+	return plan, nil
 }
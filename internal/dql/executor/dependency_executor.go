@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"fmt"
+)
+
+// ExecuteDependency adds or removes a single dependency edge, validating
+// the DAG and keeping the in-memory graph in sync. It writes in tx, or in a
+// transaction of its own when tx is nil.
+func ExecuteDependency(database *storage.Database, tx *Transaction, depAST *ast.DependencyQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		return executeDependency(database, tx, depAST)
+	})
+}
+
+func executeDependency(database *storage.Database, tx *Transaction, depAST *ast.DependencyQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, depAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	g, err := loadDAGGraph(db, depAST.DAGID, depAST.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	task := g.tasks[depAST.TaskID]
+
+	var deps []string
+	switch depAST.Action {
+	case "ADD":
+		for _, dep := range task.Dependencies {
+			if dep == depAST.Dependency {
+				return statusResult(0, "✅ Task '%s' already depends on '%s'", task.ID, dep), nil
+			}
+		}
+		deps = append(append(deps, task.Dependencies...), depAST.Dependency)
+	case "REMOVE":
+		for _, dep := range task.Dependencies {
+			if dep != depAST.Dependency {
+				deps = append(deps, dep)
+			}
+		}
+		if len(deps) == len(task.Dependencies) {
+			return nil, fmt.Errorf("❌ Task '%s' does not depend on '%s'", task.ID, depAST.Dependency)
+		}
+	default:
+		return nil, fmt.Errorf("❌ Unsupported dependency action: %s", depAST.Action)
+	}
+	if deps == nil {
+		deps = []string{}
+	}
+	task.Dependencies = deps
+
+	if err := validateDAGWrites(db, nil, []dagdb.DAGTask{task}); err != nil {
+		return nil, err
+	}
+	schema, err := loadSchema(database, tx, depAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	tx.writes.update(schema, g.tasks[depAST.TaskID], task)
+
+	if depAST.Action == "ADD" {
+		return statusResult(1, "✅ Task '%s' now depends on '%s'", task.ID, depAST.Dependency), nil
+	}
+	return statusResult(1, "✅ Task '%s' no longer depends on '%s'", task.ID, depAST.Dependency), nil
+}
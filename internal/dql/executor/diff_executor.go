@@ -0,0 +1,157 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// diffFields are the task fields compared between two versions of a task.
+var diffFields = []string{"name", "status", "payload", "duration", "retries"}
+
+// DAGEdge is a dependency edge: Task depends on Dependency.
+type DAGEdge struct {
+	Task       string
+	Dependency string
+}
+
+// FieldChange is a field whose value differs between the two versions of
+// a task, rendered as text.
+type FieldChange struct {
+	TaskID string
+	Field  string
+	Old    string
+	New    string
+}
+
+// DAGDiff is the structural difference between two DAGs, matching tasks
+// by ID. Added means present in the second DAG only, removed in the first
+// only. Everything is sorted by task ID.
+type DAGDiff struct {
+	AddedTasks   []dagdb.DAGTask
+	RemovedTasks []dagdb.DAGTask
+	AddedEdges   []DAGEdge
+	RemovedEdges []DAGEdge
+	Changes      []FieldChange
+}
+
+// Empty reports whether the two DAGs have the same structure and fields.
+func (d *DAGDiff) Empty() bool {
+	return len(d.AddedTasks)+len(d.RemovedTasks)+len(d.AddedEdges)+len(d.RemovedEdges)+len(d.Changes) == 0
+}
+
+// Summary counts the differences.
+func (d *DAGDiff) Summary() string {
+	return fmt.Sprintf("%d task(s) added, %d removed, %d edge(s) added, %d removed, %d field change(s)",
+		len(d.AddedTasks), len(d.RemovedTasks), len(d.AddedEdges), len(d.RemovedEdges), len(d.Changes))
+}
+
+// DiffDAGs compares the tasks of two DAGs.
+func DiffDAGs(from, to []dagdb.DAGTask) *DAGDiff {
+	a, b := newTaskGraph(from), newTaskGraph(to)
+	diff := &DAGDiff{}
+
+	for _, id := range a.ids {
+		old := a.tasks[id]
+		task, ok := b.tasks[id]
+		if !ok {
+			diff.RemovedTasks = append(diff.RemovedTasks, old)
+			continue
+		}
+		for _, field := range diffFields {
+			oldValue, newValue := getField(old, field), getField(task, field)
+			if oldValue != newValue && !(field == "payload" && sameJSON(oldValue, newValue)) {
+				diff.Changes = append(diff.Changes, FieldChange{TaskID: id, Field: field, Old: oldValue, New: newValue})
+			}
+		}
+	}
+	for _, id := range b.ids {
+		if _, ok := a.tasks[id]; !ok {
+			diff.AddedTasks = append(diff.AddedTasks, b.tasks[id])
+		}
+	}
+
+	diff.RemovedEdges = missingEdges(a, b)
+	diff.AddedEdges = missingEdges(b, a)
+	return diff
+}
+
+// missingEdges returns the edges of g that other does not have.
+func missingEdges(g, other *taskGraph) []DAGEdge {
+	var edges []DAGEdge
+	for _, id := range g.ids {
+		deps := append([]string{}, g.tasks[id].Dependencies...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if !slices.Contains(other.tasks[id].Dependencies, dep) {
+				edges = append(edges, DAGEdge{Task: id, Dependency: dep})
+			}
+		}
+	}
+	return edges
+}
+
+// sameJSON reports whether two texts are equal JSON values, whatever
+// their spacing and key order.
+func sameJSON(a, b string) bool {
+	var x, y interface{}
+	if json.Unmarshal([]byte(a), &x) != nil || json.Unmarshal([]byte(b), &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// ExecuteDiffDAG compares two DAGs, or two versions of a DAG kept in
+// different tables. The result has one row per difference.
+func ExecuteDiffDAG(database *storage.Database, tx *Transaction, diffAST *ast.DiffQueryAST) (*ResultSet, error) {
+	from, err := loadDAG(database, tx, diffAST.Table, diffAST.DAGID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := loadDAG(database, tx, diffAST.OtherTable, diffAST.OtherDAGID)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffDAGs(from, to)
+
+	result := DiffResult(diff)
+	if diff.Empty() {
+		result.Message = fmt.Sprintf("✅ DAG '%s' and DAG '%s' are identical", diffAST.DAGID, diffAST.OtherDAGID)
+	} else {
+		result.Message = fmt.Sprintf("🔍 DAG '%s' → '%s': %s", diffAST.DAGID, diffAST.OtherDAGID, diff.Summary())
+	}
+	return result, nil
+}
+
+// DiffResult lays out a diff as rows of change, id, field, old and new.
+// Edges are changes of the dependencies field of the dependent task.
+func DiffResult(d *DAGDiff) *ResultSet {
+	result := &ResultSet{Columns: []Column{
+		{Name: "change", Type: TypeString},
+		{Name: "id", Type: TypeString},
+		{Name: "field", Type: TypeString},
+		{Name: "old", Type: TypeString},
+		{Name: "new", Type: TypeString},
+	}}
+	for _, task := range d.AddedTasks {
+		result.Rows = append(result.Rows, []interface{}{"task_added", task.ID, nil, nil, nil})
+	}
+	for _, task := range d.RemovedTasks {
+		result.Rows = append(result.Rows, []interface{}{"task_removed", task.ID, nil, nil, nil})
+	}
+	for _, edge := range d.AddedEdges {
+		result.Rows = append(result.Rows, []interface{}{"edge_added", edge.Task, "dependencies", nil, edge.Dependency})
+	}
+	for _, edge := range d.RemovedEdges {
+		result.Rows = append(result.Rows, []interface{}{"edge_removed", edge.Task, "dependencies", edge.Dependency, nil})
+	}
+	for _, change := range d.Changes {
+		result.Rows = append(result.Rows, []interface{}{"field_changed", change.TaskID, change.Field, change.Old, change.New})
+	}
+	return result
+}
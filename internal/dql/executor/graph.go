@@ -0,0 +1,374 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// taskGraph is the dependency graph of a single DAG, built from the stored
+// Dependencies of its tasks. Edges point from a task to the tasks that
+// depend on it, i.e. in execution order.
+//
+// The store's own DAGGraph (db.Graph()) cannot stand in for it: it is not
+// loaded from disk when a store is opened (dagenie traverse fills it by
+// hand first), it only follows a transaction's writes once they are
+// committed, and it only holds the tasks of the dag table. Building the
+// graph of one DAG from its tasks is cheap and always matches what the
+// statement reads.
+type taskGraph struct {
+	tasks      map[string]dagdb.DAGTask // task ID → task
+	ids        []string                 // task IDs in a stable order
+	dependents map[string][]string      // task ID → IDs of tasks depending on it
+}
+
+func newTaskGraph(tasks []dagdb.DAGTask) *taskGraph {
+	g := &taskGraph{
+		tasks:      make(map[string]dagdb.DAGTask, len(tasks)),
+		dependents: make(map[string][]string),
+	}
+	for _, task := range tasks {
+		g.tasks[task.ID] = task
+		g.ids = append(g.ids, task.ID)
+	}
+	sort.Strings(g.ids)
+	for _, id := range g.ids {
+		for _, dep := range g.tasks[id].Dependencies {
+			g.dependents[dep] = append(g.dependents[dep], id)
+		}
+	}
+	return g
+}
+
+// dependencies returns the IDs of the tasks id depends on that exist in
+// the graph.
+func (g *taskGraph) dependencies(id string) []string {
+	var deps []string
+	for _, dep := range g.tasks[id].Dependencies {
+		if _, ok := g.tasks[dep]; ok {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// walk does a breadth-first traversal from id using next for the
+// neighbours, stopping after maxDepth levels (0 for unlimited). The start
+// task itself is not included.
+func (g *taskGraph) walk(id string, maxDepth int, next func(string) []string) []string {
+	visited := map[string]bool{id: true}
+	var result []string
+	frontier := []string{id}
+	for depth := 1; len(frontier) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		var nextFrontier []string
+		for _, cur := range frontier {
+			for _, n := range next(cur) {
+				if visited[n] {
+					continue
+				}
+				visited[n] = true
+				if _, ok := g.tasks[n]; ok {
+					result = append(result, n)
+					nextFrontier = append(nextFrontier, n)
+				}
+			}
+		}
+		frontier = nextFrontier
+	}
+	return result
+}
+
+// descendants returns every task that directly or transitively depends on id.
+func (g *taskGraph) descendants(id string, maxDepth int) []string {
+	return g.walk(id, maxDepth, func(cur string) []string { return g.dependents[cur] })
+}
+
+// ancestors returns every task id directly or transitively depends on.
+func (g *taskGraph) ancestors(id string, maxDepth int) []string {
+	return g.walk(id, maxDepth, g.dependencies)
+}
+
+// path returns the shortest chain of tasks leading from one task to
+// another along dependency edges, both ends included, or nil if the second
+// task is not downstream of the first.
+func (g *taskGraph) path(from, to string) []string {
+	if from == to {
+		return []string{from}
+	}
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, n := range g.dependents[cur] {
+			if _, seen := prev[n]; seen {
+				continue
+			}
+			prev[n] = cur
+			if n == to {
+				var path []string
+				for at := to; at != ""; at = prev[at] {
+					path = append([]string{at}, path...)
+				}
+				return path
+			}
+			queue = append(queue, n)
+		}
+	}
+	return nil
+}
+
+// tasksFor maps task IDs back to tasks, preserving order.
+func (g *taskGraph) tasksFor(ids []string) []dagdb.DAGTask {
+	tasks := make([]dagdb.DAGTask, 0, len(ids))
+	for _, id := range ids {
+		tasks = append(tasks, g.tasks[id])
+	}
+	return tasks
+}
+
+// loadDAGGraph loads the graph of the DAG containing taskID. When dagID is
+// empty the DAG is looked up from the task, which must then be unique
+// across DAGs.
+func loadDAGGraph(db *storage.Table, dagID, taskID string) (*taskGraph, error) {
+	if dagID == "" {
+		all, err := db.ListAllTasks()
+		if err != nil {
+			return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		for _, task := range all {
+			if task.ID != taskID {
+				continue
+			}
+			if dagID != "" && dagID != task.DAGID {
+				return nil, fmt.Errorf("❌ Task '%s' exists in several DAGs, add IN DAG '<dagid>'", taskID)
+			}
+			dagID = task.DAGID
+		}
+		if dagID == "" {
+			return nil, fmt.Errorf("❌ Task '%s' not found", taskID)
+		}
+	}
+
+	tasks, err := db.ListTasksByDAG(dagID)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+	g := newTaskGraph(tasks)
+	if _, ok := g.tasks[taskID]; !ok {
+		return nil, fmt.Errorf("❌ Task '%s' not found in DAG '%s'", taskID, dagID)
+	}
+	return g, nil
+}
+
+// loadGraphSourceTasks resolves a DESCENDANTS, ANCESTORS or PATH source to
+// the tasks it covers, in traversal order.
+func loadGraphSourceTasks(db *storage.Table, src *ast.GraphSource) ([]dagdb.DAGTask, error) {
+	g, err := loadDAGGraph(db, src.DAGID, src.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch src.Kind {
+	case "DESCENDANTS":
+		return g.tasksFor(g.descendants(src.TaskID, src.MaxDepth)), nil
+	case "ANCESTORS":
+		return g.tasksFor(g.ancestors(src.TaskID, src.MaxDepth)), nil
+	case "PATH":
+		if _, ok := g.tasks[src.TargetID]; !ok {
+			return nil, fmt.Errorf("❌ Task '%s' not found in DAG '%s'", src.TargetID, g.tasks[src.TaskID].DAGID)
+		}
+		path := g.path(src.TaskID, src.TargetID)
+		if path == nil {
+			return nil, fmt.Errorf("❌ No path from '%s' to '%s' in DAG '%s'", src.TaskID, src.TargetID, g.tasks[src.TaskID].DAGID)
+		}
+		return g.tasksFor(path), nil
+	default:
+		return nil, fmt.Errorf("❌ Unsupported graph source: %s", src.Kind)
+	}
+}
+
+// topoLevels orders the tasks so that every task comes after the tasks it
+// depends on, and assigns each its level: the longest distance from a root
+// task. Tasks on the same level have no dependency between them and can run
+// in parallel. Dependencies on tasks outside the graph are ignored.
+func (g *taskGraph) topoLevels() ([]string, map[string]int, error) {
+	inDegree := make(map[string]int, len(g.ids))
+	for _, id := range g.ids {
+		inDegree[id] = len(g.dependencies(id))
+	}
+
+	levels := make(map[string]int, len(g.ids))
+	var queue []string
+	for _, id := range g.ids {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+
+	order := make([]string, 0, len(g.ids))
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		order = append(order, cur)
+		for _, n := range g.dependents[cur] {
+			if _, ok := g.tasks[n]; !ok {
+				continue
+			}
+			if levels[cur]+1 > levels[n] {
+				levels[n] = levels[cur] + 1
+			}
+			inDegree[n]--
+			if inDegree[n] == 0 {
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	if len(order) < len(g.ids) {
+		return nil, nil, g.cycleError()
+	}
+
+	// Present level by level, keeping Kahn's order within a level.
+	sort.SliceStable(order, func(i, j int) bool { return levels[order[i]] < levels[order[j]] })
+	return order, levels, nil
+}
+
+// findCycle returns one dependency cycle as a closed list of task IDs
+// (first == last), or nil if the graph is acyclic.
+func (g *taskGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		inStack
+		done
+	)
+	state := make(map[string]int, len(g.ids))
+	var stack []string
+
+	var visit func(id string) []string
+	visit = func(id string) []string {
+		state[id] = inStack
+		stack = append(stack, id)
+		for _, dep := range g.dependencies(id) {
+			switch state[dep] {
+			case inStack:
+				for i, s := range stack {
+					if s == dep {
+						// The stack runs downstream → upstream; report it in execution order.
+						cycle := append([]string{}, stack[i:]...)
+						for l, r := 0, len(cycle)-1; l < r; l, r = l+1, r-1 {
+							cycle[l], cycle[r] = cycle[r], cycle[l]
+						}
+						return append(cycle, cycle[0])
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, id := range g.ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// cycleError describes a cycle in the graph, naming every task on it.
+func (g *taskGraph) cycleError() error {
+	cycle := g.findCycle()
+	dagID := ""
+	if len(cycle) > 0 {
+		dagID = g.tasks[cycle[0]].DAGID
+	}
+	return fmt.Errorf("❌ Cycle detected in DAG '%s': %s", dagID, strings.Join(cycle, " -> "))
+}
+
+// TaskLevel is a task together with its execution level.
+type TaskLevel struct {
+	Task  dagdb.DAGTask
+	Level int
+}
+
+// TopologicalOrder returns the tasks of a DAG in a valid execution order
+// with their levels, or an error naming a cycle if the DAG is not acyclic.
+func TopologicalOrder(db *storage.Table, dagID string) ([]TaskLevel, error) {
+	tasks, err := db.ListTasksByDAG(dagID)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("❌ DAG '%s' has no tasks", dagID)
+	}
+
+	g := newTaskGraph(tasks)
+	order, levels, err := g.topoLevels()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TaskLevel, 0, len(order))
+	for _, id := range order {
+		result = append(result, TaskLevel{Task: g.tasks[id], Level: levels[id]})
+	}
+	return result, nil
+}
+
+// taskKey identifies a task across DAGs.
+func taskKey(task dagdb.DAGTask) string {
+	return task.DAGID + "\x00" + task.ID
+}
+
+// orderTopologically sorts tasks into execution order, DAG by DAG, and
+// returns the level of each task keyed by taskKey. Levels are computed on
+// the full DAG so that filtering rows does not change them.
+func orderTopologically(db *storage.Table, tasks []dagdb.DAGTask) ([]dagdb.DAGTask, map[string]int, error) {
+	byDAG := make(map[string][]dagdb.DAGTask)
+	var dagIDs []string
+	for _, task := range tasks {
+		if _, ok := byDAG[task.DAGID]; !ok {
+			dagIDs = append(dagIDs, task.DAGID)
+		}
+		byDAG[task.DAGID] = append(byDAG[task.DAGID], task)
+	}
+	sort.Strings(dagIDs)
+
+	levels := make(map[string]int, len(tasks))
+	ordered := make([]dagdb.DAGTask, 0, len(tasks))
+	for _, dagID := range dagIDs {
+		all, err := db.ListTasksByDAG(dagID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		order, dagLevels, err := newTaskGraph(all).topoLevels()
+		if err != nil {
+			return nil, nil, err
+		}
+		position := make(map[string]int, len(order))
+		for i, id := range order {
+			position[id] = i
+		}
+
+		dagTasks := byDAG[dagID]
+		sort.SliceStable(dagTasks, func(i, j int) bool {
+			return position[dagTasks[i].ID] < position[dagTasks[j].ID]
+		})
+		for _, task := range dagTasks {
+			levels[taskKey(task)] = dagLevels[task.ID]
+		}
+		ordered = append(ordered, dagTasks...)
+	}
+	return ordered, levels, nil
+}
@@ -3,60 +3,304 @@ package executor
 import (
 	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
 	"dagenie/utils"
-	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-func ExecuteInsert(db *dagdb.DAGDB, insertAST *ast.InsertQueryAST) (string, error) {
-	if insertAST.Table != "dag" {
-		return "", fmt.Errorf("❌ Unsupported table: %s", insertAST.Table)
+// coreInsertFields are the built-in fields an INSERT sets, in declared
+// order.
+var coreInsertFields = []string{"id", "name", "status", "payload", "dependencies", "dagid", "duration", "retries"}
+
+// coreInsertDefaults are the values of built-in fields an INSERT leaves
+// out. They match what the CLI insert command stores. id, name and dagid
+// have no default.
+var coreInsertDefaults = map[string]string{
+	"status":       "pending",
+	"payload":      "{}",
+	"dependencies": "[]",
+	"duration":     "0",
+	"retries":      "0",
+}
+
+// ExecuteInsert inserts one or more tasks. Omitted built-in fields get
+// coreInsertDefaults, omitted columns their DEFAULT, and the result
+// message lists what was defaulted. With RETURNING, the result also has
+// the listed fields of every inserted task, including its new _id.
+//
+// The rows are validated together against the existing tasks, so rows may
+// depend on each other, and are then written as one batch in tx, or in a
+// transaction of their own when tx is nil.
+func ExecuteInsert(database *storage.Database, tx *Transaction, insertAST *ast.InsertQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		return executeInsert(database, tx, insertAST)
+	})
+}
+
+func executeInsert(database *storage.Database, tx *Transaction, insertAST *ast.InsertQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, insertAST.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := loadSchema(database, tx, insertAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	returning, err := schema.returningFields(insertAST.Returning)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a column list, values follow the declared column order
+	columns := insertAST.Columns
+	declared := schema.insertFields()
+	if len(columns) == 0 {
+		if len(insertAST.Rows[0]) > len(declared) {
+			return nil, fmt.Errorf("❌ Too many values: table %s has %d columns", insertAST.Table, len(declared))
+		}
+		columns = declared[:len(insertAST.Rows[0])]
+	}
+
+	tasks := make([]dagdb.DAGTask, 0, len(insertAST.Rows))
+	var defaulted []string
+	for i, row := range insertAST.Rows {
+		task, rowDefaults, err := schema.buildInsertTask(declared, columns, row)
+		if err != nil {
+			if len(insertAST.Rows) > 1 {
+				return nil, fmt.Errorf("❌ Row %d: %v", i+1, strings.TrimPrefix(err.Error(), "❌ "))
+			}
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		for _, field := range rowDefaults {
+			if !slices.Contains(defaulted, field) {
+				defaulted = append(defaulted, field)
+			}
+		}
+	}
+
+	if insertAST.OnConflict != nil {
+		return schema.executeUpsert(tx, db, declared, tasks, defaulted, insertAST.OnConflict, returning)
+	}
+
+	// Dependencies must exist in the same DAG or the batch and must not
+	// close a cycle
+	if err := validateDAGWrites(db, nil, tasks); err != nil {
+		return nil, err
+	}
+
+	writes := make([]storage.TaskWrite, 0, len(tasks))
+	for _, task := range tasks {
+		writes = append(writes, insertWrite(schema, task))
+	}
+	tx.writes.add(writes...)
+
+	message := fmt.Sprintf("✅ Inserted task ID=%s", tasks[0].ID)
+	if len(tasks) > 1 {
+		message = fmt.Sprintf("✅ Inserted %d task(s)", len(tasks))
+	}
+	if len(defaulted) > 0 {
+		message += fmt.Sprintf(" (defaulted: %s)", strings.Join(defaulted, ", "))
+	}
+	return schema.withReturning(statusResult(len(tasks), "%s", message), returning, tasks), nil
+}
+
+// executeUpsert finishes an INSERT ... ON CONFLICT. Rows whose task key
+// is new are inserted; for existing keys DO NOTHING skips the row and DO
+// UPDATE applies its SET list to the stored task, keeping its ObjectID.
+// The result has one row per VALUES row telling what happened to it, or
+// with RETURNING, the listed fields of the inserted and updated tasks.
+func (s *tableSchema) executeUpsert(tx *Transaction, db *storage.Table, declared []string, tasks []dagdb.DAGTask, defaulted []string, onConflict *ast.OnConflictAST, returning []string) (*ResultSet, error) {
+	if err := s.validateSetFields(onConflict.SetFields); err != nil {
+		return nil, err
+	}
+	for _, set := range onConflict.SetFields {
+		if set.Excluded != "" && !slices.Contains(declared, set.Excluded) {
+			return nil, fmt.Errorf("❌ Unknown column: EXCLUDED.%s", set.Excluded)
+		}
+		if set.Field == "id" || set.Field == "dagid" {
+			return nil, fmt.Errorf("❌ ON CONFLICT DO UPDATE cannot change the task key field %s", set.Field)
+		}
 	}
 
-	// Map columns to values (lowercased keys)
+	type upsertRow struct {
+		task     dagdb.DAGTask
+		previous *dagdb.DAGTask
+		action   string
+	}
+	existing := make(map[string]dagdb.DAGTask)
+	loaded := make(map[string]bool)
+	seen := make(map[string]bool)
+	var rows []upsertRow
+	var saved []dagdb.DAGTask
+	for _, task := range tasks {
+		key := taskKey(task)
+		if seen[key] {
+			return nil, fmt.Errorf("❌ ON CONFLICT cannot affect task '%s' in DAG '%s' twice", task.ID, task.DAGID)
+		}
+		seen[key] = true
+
+		if !loaded[task.DAGID] {
+			current, err := db.ListTasksByDAG(task.DAGID)
+			if err != nil {
+				return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+			}
+			for _, t := range current {
+				existing[taskKey(t)] = t
+			}
+			loaded[task.DAGID] = true
+		}
+
+		previous, conflict := existing[key]
+		switch {
+		case !conflict:
+			rows = append(rows, upsertRow{task: task, action: "inserted"})
+			saved = append(saved, task)
+		case onConflict.DoNothing:
+			rows = append(rows, upsertRow{task: previous, action: "skipped"})
+		default:
+			setFields := make([]ast.Assignment, 0, len(onConflict.SetFields))
+			for _, set := range onConflict.SetFields {
+				if set.Excluded != "" {
+					set.Value = s.fieldLiteral(task, set.Excluded)
+				}
+				setFields = append(setFields, set)
+			}
+			updated := previous
+			if _, err := s.applySetFields(&updated, setFields); err != nil {
+				return nil, err
+			}
+			prev := previous
+			rows = append(rows, upsertRow{task: updated, previous: &prev, action: "updated"})
+			saved = append(saved, updated)
+		}
+	}
+
+	// Updated tasks keep their ObjectID, so validation sees them as
+	// replacing the stored version
+	if err := validateDAGWrites(db, nil, saved); err != nil {
+		return nil, err
+	}
+
+	var writes []storage.TaskWrite
+	for _, row := range rows {
+		switch row.action {
+		case "inserted":
+			writes = append(writes, insertWrite(s, row.task))
+		case "updated":
+			writes = append(writes, updateWrite(s, *row.previous, row.task))
+		}
+	}
+	tx.writes.add(writes...)
+
+	counts := map[string]int{}
+	result := &ResultSet{Columns: []Column{
+		{Name: "dagid", Type: TypeString},
+		{Name: "id", Type: TypeString},
+		{Name: "_id", Type: TypeString},
+		{Name: "action", Type: TypeString},
+	}}
+	var written []dagdb.DAGTask
+	for _, row := range rows {
+		counts[row.action]++
+		result.Rows = append(result.Rows, []interface{}{row.task.DAGID, row.task.ID, row.task.ObjectID, row.action})
+		if row.action != "skipped" {
+			written = append(written, row.task)
+		}
+	}
+	result.RowsAffected = counts["inserted"] + counts["updated"]
+	result.Message = fmt.Sprintf("✅ Inserted %d, updated %d, skipped %d task(s)", counts["inserted"], counts["updated"], counts["skipped"])
+	if len(defaulted) > 0 && counts["inserted"] > 0 {
+		result.Message += fmt.Sprintf(" (defaulted: %s)", strings.Join(defaulted, ", "))
+	}
+	return s.withReturning(result, returning, written), nil
+}
+
+// fieldLiteral reads a field of a task back as the literal that would set
+// it, for SET f = EXCLUDED.g.
+func (s *tableSchema) fieldLiteral(task dagdb.DAGTask, field string) ast.Literal {
+	if def, ok := s.column(field); ok {
+		value := s.columnValue(task, def)
+		if value == nil {
+			return ast.Literal{Kind: ast.LiteralNull}
+		}
+		return ast.Literal{Kind: ast.LiteralString, Text: columnText(value)}
+	}
+	return ast.Literal{Kind: ast.LiteralString, Text: getField(task, field)}
+}
+
+// buildInsertTask turns one VALUES row into a new task.
+func (s *tableSchema) buildInsertTask(declared, columns []string, row []ast.Literal) (dagdb.DAGTask, []string, error) {
+	// Map columns to values (lowercased keys); user-defined columns keep
+	// their literal so that NULL can be told apart
 	data := make(map[string]string)
-	for i, col := range insertAST.Columns {
-		data[strings.ToLower(col)] = insertAST.Values[i]
+	literals := make(map[string]ast.Literal)
+	for i, col := range columns {
+		field := strings.ToLower(col)
+		if !slices.Contains(declared, field) {
+			return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Unknown column: %s", col)
+		}
+		if _, ok := s.column(field); ok {
+			literals[field] = row[i]
+			continue
+		}
+		value, err := builtinLiteral(field, row[i])
+		if err != nil {
+			return dagdb.DAGTask{}, nil, err
+		}
+		data[field] = value
 	}
 
 	// Validate required fields
-	requiredFields := []string{"id", "name", "status", "payload", "dependencies", "dagid", "duration", "retries"}
-	for _, field := range requiredFields {
+	for _, field := range []string{"id", "name", "dagid"} {
 		if _, ok := data[field]; !ok {
-			return "", fmt.Errorf("❌ Missing required field: %s", field)
+			return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Missing required field: %s", field)
+		}
+	}
+
+	// Fill in omitted optional fields
+	var defaulted []string
+	for _, field := range coreInsertFields {
+		if _, ok := data[field]; ok {
+			continue
+		}
+		if value, ok := coreInsertDefaults[field]; ok {
+			data[field] = value
+			defaulted = append(defaulted, field)
 		}
 	}
 
 	// Validate 'dagid' - no spaces
 	dagid := data["dagid"]
 	if strings.Contains(dagid, " ") {
-		return "", fmt.Errorf("❌ Invalid value for dagid: cannot contain spaces")
+		return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Invalid value for dagid: cannot contain spaces")
 	}
 
 	// Validate 'name' - no spaces
 	name := data["name"]
 	if strings.Contains(name, " ") {
-		return "", fmt.Errorf("❌ Invalid value for name: cannot contain spaces")
+		return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Invalid value for name: cannot contain spaces")
 	}
 
 	// Convert duration and retries to int
 	durationInt, err := strconv.Atoi(data["duration"])
 	if err != nil {
-		return "", fmt.Errorf("❌ Invalid duration value: %v", err)
+		return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Invalid duration value: %v", err)
 	}
 
 	retriesInt, err := strconv.Atoi(data["retries"])
 	if err != nil {
-		return "", fmt.Errorf("❌ Invalid retries value: %v", err)
+		return dagdb.DAGTask{}, nil, fmt.Errorf("❌ Invalid retries value: %v", err)
 	}
 
 	// Parse dependencies - must be a JSON array string
-	var dependencies []string
-	err = json.Unmarshal([]byte(data["dependencies"]), &dependencies)
+	dependencies, err := parseDependencies(data["dependencies"])
 	if err != nil {
-		return "", fmt.Errorf("❌ Invalid dependencies format: %v", err)
+		return dagdb.DAGTask{}, nil, err
 	}
 
 	// Create task object
@@ -72,16 +316,19 @@ func ExecuteInsert(db *dagdb.DAGDB, insertAST *ast.InsertQueryAST) (string, erro
 		Dependencies: dependencies,
 	}
 
-	// Save to database
-	err = db.SaveTask(task)
+	// User-defined columns: given values, then defaults
+	provided := make(map[string]bool)
+	for _, def := range s.columns {
+		if lit, ok := literals[def.Name]; ok {
+			if _, err := s.setColumnLiteral(task, def, lit); err != nil {
+				return dagdb.DAGTask{}, nil, err
+			}
+			provided[def.Name] = true
+		}
+	}
+	columnDefaults, err := s.applyDefaults(task, provided)
 	if err != nil {
-		return "", fmt.Errorf("❌ Insert Failed: %v", err)
+		return dagdb.DAGTask{}, nil, err
 	}
-	// ✅ Add to in-memory graph
-	db.Graph().AddTask(task)
-
-	fmt.Println("I8")
-	fmt.Println("📊 Current Graph Size:", len(db.Graph().AllTasks()))
-
-	return fmt.Sprintf("✅ Inserted task ID=%s", task.ID), nil
+	return task, append(defaulted, columnDefaults...), nil
 }
@@ -0,0 +1,216 @@
+package executor
+
+import (
+	"bytes"
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonField reports whether a field holds a JSON document that path
+// expressions and JSON_SET work on: payload or a json column.
+func (s *tableSchema) jsonField(field string) bool {
+	if def, ok := s.column(field); ok {
+		return def.Type == storage.ColumnJSON
+	}
+	return strings.ToLower(field) == "payload"
+}
+
+// jsonDocument decodes the JSON document in a field of a task. ok is false
+// when the field is empty or does not hold valid JSON, as payloads written
+// as plain text do not.
+func (s *tableSchema) jsonDocument(task dagdb.DAGTask, field string) (doc interface{}, ok bool) {
+	text := s.getField(task, field)
+	if text == "" {
+		return nil, false
+	}
+	doc, err := decodeJSON(text)
+	return doc, err == nil
+}
+
+// decodeJSON decodes JSON text keeping numbers as json.Number, so that
+// documents written back keep them exactly as they were.
+func decodeJSON(text string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return doc, nil
+}
+
+// encodeJSON is the compact JSON text of a decoded document.
+func encodeJSON(doc interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(doc)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// lookupJSON follows a path into a decoded document. ok is false when a
+// key or index along the path is missing.
+func lookupJSON(doc interface{}, steps []ast.PathStep) (interface{}, bool) {
+	for _, step := range steps {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[step.Key]
+			if step.IsIndex || !ok {
+				return nil, false
+			}
+			doc = value
+		case []interface{}:
+			if !step.IsIndex || step.Index >= len(node) {
+				return nil, false
+			}
+			doc = node[step.Index]
+		default:
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// pathValue evaluates a path field against a task. Scalars come back as
+// a string, float64 or bool so they compare like other fields, objects and
+// arrays as json.RawMessage. A missing value or JSON null is nil.
+func (s *tableSchema) pathValue(task dagdb.DAGTask, pf ast.PathField) interface{} {
+	doc, ok := s.jsonDocument(task, pf.Field)
+	if !ok {
+		return nil
+	}
+	value, ok := lookupJSON(doc, pf.Path.Steps)
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string, bool:
+		return v
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	default:
+		return json.RawMessage(encodeJSON(v))
+	}
+}
+
+// pathResultValue is the value of a path field in a result row: the JSON
+// at the path for ->, its text for ->>.
+func (s *tableSchema) pathResultValue(task dagdb.DAGTask, pf ast.PathField) interface{} {
+	value := s.pathValue(task, pf)
+	if value == nil {
+		return nil
+	}
+	if pf.Arrow == "->>" {
+		return columnText(value)
+	}
+	if raw, ok := value.(json.RawMessage); ok {
+		return raw
+	}
+	return json.RawMessage(encodeJSON(value))
+}
+
+// evalJSONSet computes the document a JSON_SET call in SET produces for a
+// task. Every path is set in turn, replacing the value there or adding it
+// along with any missing objects on the way. An array index may append
+// one element past the end. An empty field counts as {}.
+func (s *tableSchema) evalJSONSet(task dagdb.DAGTask, set *ast.JSONSet) (string, error) {
+	if !s.jsonField(set.Field) {
+		return "", fmt.Errorf("❌ JSON_SET needs payload or a json column, not %s", set.Field)
+	}
+	var doc interface{} = map[string]interface{}{}
+	if text := s.getField(task, set.Field); text != "" {
+		var err error
+		if doc, err = decodeJSON(text); err != nil {
+			return "", fmt.Errorf("❌ JSON_SET: the %s of task '%s' in DAG '%s' is not valid JSON", set.Field, task.ID, task.DAGID)
+		}
+	}
+	for i, path := range set.Paths {
+		value, err := literalJSON(set.Values[i])
+		if err != nil {
+			return "", err
+		}
+		if doc, err = setJSON(doc, path.Steps, value); err != nil {
+			return "", fmt.Errorf("❌ JSON_SET cannot set %s on task '%s' in DAG '%s': %v", path.Text, task.ID, task.DAGID, err)
+		}
+	}
+	return encodeJSON(doc), nil
+}
+
+// setJSON returns doc with value stored at the path.
+func setJSON(doc interface{}, steps []ast.PathStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+	step := steps[0]
+	if step.IsIndex {
+		array, ok := doc.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("[%d] of a value that is not an array", step.Index)
+		}
+		if step.Index > len(array) {
+			return nil, fmt.Errorf("index %d is past the end of an array of %d", step.Index, len(array))
+		}
+		var child interface{}
+		if step.Index < len(array) {
+			child = array[step.Index]
+		}
+		child, err := setJSON(child, steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		if step.Index == len(array) {
+			return append(array, child), nil
+		}
+		array[step.Index] = child
+		return array, nil
+	}
+
+	object, ok := doc.(map[string]interface{})
+	if doc == nil {
+		object, ok = map[string]interface{}{}, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("key %s of a value that is not an object", step.Key)
+	}
+	child, err := setJSON(object[step.Key], steps[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	object[step.Key] = child
+	return object, nil
+}
+
+// literalJSON is the JSON value of a literal: strings as JSON strings, and
+// numbers, booleans, NULL and JSON literals as themselves.
+func literalJSON(lit ast.Literal) (interface{}, error) {
+	switch lit.Kind {
+	case ast.LiteralString:
+		return lit.Text, nil
+	case ast.LiteralNumber:
+		if !json.Valid([]byte(lit.Text)) {
+			return nil, fmt.Errorf("❌ Invalid number in JSON_SET: %s", lit.Text)
+		}
+		return json.Number(lit.Text), nil
+	case ast.LiteralBool:
+		return lit.Text == "true", nil
+	case ast.LiteralNull:
+		return nil, nil
+	case ast.LiteralJSON:
+		return decodeJSON(lit.Text)
+	default:
+		return nil, fmt.Errorf("❌ Unsupported value in JSON_SET")
+	}
+}
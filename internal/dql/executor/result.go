@@ -0,0 +1,169 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ColumnType is the type of the values in one result column.
+type ColumnType string
+
+const (
+	TypeString    ColumnType = "string"
+	TypeInt       ColumnType = "int"
+	TypeFloat     ColumnType = "float"
+	TypeBool      ColumnType = "bool"
+	TypeTimestamp ColumnType = "timestamp"
+	TypeJSON      ColumnType = "json"
+)
+
+// Column describes one column of a ResultSet.
+type Column struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type"`
+}
+
+// ResultSet is the structured outcome of a statement. Queries fill Columns
+// and Rows; row values are string, int, float64, bool, json.RawMessage or
+// nil, matching the column type, with timestamps as RFC 3339 strings.
+// Writes report RowsAffected. Message holds any human-readable status
+// line, and Elapsed is set by the dispatcher. Rendering is left to the
+// format package.
+type ResultSet struct {
+	Columns      []Column        `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	RowsAffected int             `json:"rows_affected"`
+	Message      string          `json:"message,omitempty"`
+	Elapsed      time.Duration   `json:"-"`
+}
+
+// statusResult is the ResultSet of a statement that returns no rows.
+func statusResult(affected int, format string, args ...interface{}) *ResultSet {
+	return &ResultSet{RowsAffected: affected, Message: fmt.Sprintf(format, args...)}
+}
+
+// columnType returns the type of a task field. A JSON path expression is
+// JSON with -> and text with ->>.
+func (s *tableSchema) columnType(field string) ColumnType {
+	if pf, ok := s.pathField(field); ok {
+		if pf.Arrow == "->>" {
+			return TypeString
+		}
+		return TypeJSON
+	}
+	if def, ok := s.column(field); ok {
+		return resultColumnType(def)
+	}
+	return columnType(field)
+}
+
+// columnType returns the type of a built-in task field.
+func columnType(field string) ColumnType {
+	switch strings.ToLower(field) {
+	case "payload", "dependencies":
+		return TypeJSON
+	case "level":
+		return TypeInt
+	}
+	if isNumericField(field) {
+		return TypeInt
+	}
+	return TypeString
+}
+
+// taskColumns describes the given task fields as result columns.
+func (s *tableSchema) taskColumns(fields []string) []Column {
+	columns := make([]Column, 0, len(fields))
+	for _, field := range fields {
+		name := strings.ToLower(field)
+		if _, ok := s.pathField(field); ok {
+			name = field // JSON paths are case-sensitive
+		}
+		columns = append(columns, Column{Name: name, Type: s.columnType(field)})
+	}
+	return columns
+}
+
+// typedValue converts a rendered field value to the Go value of its column
+// type.
+func typedValue(field, value string) interface{} {
+	switch columnType(field) {
+	case TypeInt:
+		var n int
+		if _, err := fmt.Sscanf(value, "%d", &n); err != nil {
+			return nil
+		}
+		return n
+	case TypeJSON:
+		return jsonValue(value)
+	default:
+		return value
+	}
+}
+
+// jsonValue keeps valid JSON text as raw JSON and anything else as a string.
+func jsonValue(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	if json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	return value
+}
+
+// resultValue returns the value of a task field as stored in a result row.
+func (s *tableSchema) resultValue(task dagdb.DAGTask, field string) interface{} {
+	if pf, ok := s.pathField(field); ok {
+		return s.pathResultValue(task, pf)
+	}
+	if def, ok := s.column(field); ok {
+		value := s.columnValue(task, def)
+		if t, ok := value.(time.Time); ok {
+			return columnText(t)
+		}
+		return value
+	}
+	return typedValue(field, getField(task, field))
+}
+
+// returningFields expands and checks the RETURNING list of a write
+// statement. It is nil when the statement has none.
+func (s *tableSchema) returningFields(fields []string) ([]string, error) {
+	if len(fields) == 1 && fields[0] == "*" {
+		return s.allFields(), nil
+	}
+	for _, field := range fields {
+		if !s.validField(field) {
+			return nil, fmt.Errorf("❌ Unknown field in RETURNING: %s", field)
+		}
+	}
+	return fields, nil
+}
+
+// withReturning adds the RETURNING rows of a write statement to its
+// result, one per written task, as SELECT would return them.
+func (s *tableSchema) withReturning(result *ResultSet, fields []string, tasks []dagdb.DAGTask) *ResultSet {
+	if fields == nil {
+		return result
+	}
+	result.Columns = s.taskColumns(fields)
+	result.Rows = taskRows(tasks, fields, s.resultValue)
+	return result
+}
+
+// taskRows builds the typed rows of a task listing.
+func taskRows(tasks []dagdb.DAGTask, fields []string, valueOf func(dagdb.DAGTask, string) interface{}) [][]interface{} {
+	rows := make([][]interface{}, 0, len(tasks))
+	for _, task := range tasks {
+		row := make([]interface{}, 0, len(fields))
+		for _, field := range fields {
+			row = append(row, valueOf(task, field))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,371 @@
+package executor
+
+import (
+	"bytes"
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coreFields are the built-in task fields in SELECT * order.
+var coreFields = []string{"id", "name", "status", "payload", "dependencies", "dagid", "duration", "retries", "_id"}
+
+// tableSchema is the set of fields a statement on one table can use: the
+// built-in task fields plus the table's user-defined columns.
+//
+// DAGTask has no room for extra attributes, so the storage keeps the
+// values of user-defined columns apart from the tasks, keyed by ObjectID
+// (see storage.Table). A schema holds the values loaded with it and those
+// the statement set, and the writes of the statement carry them to the
+// store. Tasks without values, such as those written by the CLI insert
+// command, read their column defaults.
+type tableSchema struct {
+	table   string
+	columns []storage.ColumnDef
+	byName  map[string]storage.ColumnDef
+	stored  map[string]storage.ColumnValues
+	changed map[string]storage.ColumnValues
+	paths   map[string]parsedPath // field name → JSON path expression, see pathField
+}
+
+// parsedPath is a field name parsed by pathField; ok is false for plain
+// field names.
+type parsedPath struct {
+	field ast.PathField
+	ok    bool
+}
+
+// timestampLayouts are the accepted spellings of timestamp literals.
+var timestampLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+func newTableSchema(table string, columns []storage.ColumnDef) *tableSchema {
+	s := &tableSchema{
+		table:   table,
+		columns: columns,
+		byName:  make(map[string]storage.ColumnDef, len(columns)),
+		changed: make(map[string]storage.ColumnValues),
+		paths:   make(map[string]parsedPath),
+	}
+	for _, col := range columns {
+		s.byName[col.Name] = col
+	}
+	return s
+}
+
+// loadSchema returns the schema of a table with its column values, as a
+// statement in tx sees them.
+func loadSchema(database *storage.Database, tx *Transaction, table string) (*tableSchema, error) {
+	columns, err := database.Columns(table)
+	if err != nil {
+		return nil, err
+	}
+	s := newTableSchema(table, columns)
+	if len(columns) == 0 {
+		return s, nil
+	}
+	db, err := openTable(database, tx, table)
+	if err != nil {
+		return nil, err
+	}
+	if s.stored, err = db.ColumnValues(); err != nil {
+		return nil, fmt.Errorf("❌ Column value fetch error: %v", err)
+	}
+	return s, nil
+}
+
+// columnValues returns the column values of a task: those the statement
+// set, else the stored ones.
+func (s *tableSchema) columnValues(task dagdb.DAGTask) storage.ColumnValues {
+	if values, ok := s.changed[task.ObjectID]; ok {
+		return values
+	}
+	return s.stored[task.ObjectID]
+}
+
+// setColumnValues replaces the column values of a task, for a copy that
+// gets a new ObjectID.
+func (s *tableSchema) setColumnValues(task dagdb.DAGTask, values storage.ColumnValues) {
+	s.changed[task.ObjectID] = values
+}
+
+// putColumnRaw stores the JSON value of a column, reporting whether it
+// changed. Stored values are copied before the first change, so the
+// writes of the statement still see what they replace.
+func (s *tableSchema) putColumnRaw(task dagdb.DAGTask, name string, raw json.RawMessage) bool {
+	current := s.columnValues(task)
+	if old, ok := current[name]; ok && bytes.Equal(old, raw) {
+		return false
+	}
+	values, ok := s.changed[task.ObjectID]
+	if !ok {
+		values = make(storage.ColumnValues, len(current)+1)
+		for k, v := range current {
+			values[k] = v
+		}
+		s.changed[task.ObjectID] = values
+	}
+	values[name] = raw
+	return true
+}
+
+// column returns the user-defined column named field, if any.
+func (s *tableSchema) column(field string) (storage.ColumnDef, bool) {
+	def, ok := s.byName[strings.ToLower(field)]
+	return def, ok
+}
+
+// pathField reads a field name as a JSON path expression, see
+// ast.ParsePathField. A schema lives for one statement, which evaluates
+// its fields once per task, so each distinct name is parsed only once.
+func (s *tableSchema) pathField(field string) (ast.PathField, bool) {
+	if parsed, seen := s.paths[field]; seen {
+		return parsed.field, parsed.ok
+	}
+	pf, ok := ast.ParsePathField(field)
+	s.paths[field] = parsedPath{field: pf, ok: ok}
+	return pf, ok
+}
+
+// validField reports whether field is a built-in field, a column, or a
+// JSON path expression on payload or a json column.
+func (s *tableSchema) validField(field string) bool {
+	if pf, ok := s.pathField(field); ok {
+		return s.jsonField(pf.Field)
+	}
+	if validFields[strings.ToLower(field)] {
+		return true
+	}
+	_, ok := s.column(field)
+	return ok
+}
+
+// insertFields are the fields an INSERT can set, in declared order: the
+// writable built-in fields, then columns. VALUES without a column list
+// follow this order.
+func (s *tableSchema) insertFields() []string {
+	fields := append([]string{}, coreInsertFields...)
+	for _, col := range s.columns {
+		fields = append(fields, col.Name)
+	}
+	return fields
+}
+
+// allFields is the SELECT * field list: built-in fields, then columns.
+func (s *tableSchema) allFields() []string {
+	fields := append([]string{}, coreFields...)
+	for _, col := range s.columns {
+		fields = append(fields, col.Name)
+	}
+	return fields
+}
+
+// encodeColumnValue checks a literal against the column type and returns
+// the JSON stored for it. Timestamps are normalized to RFC 3339 in UTC.
+func encodeColumnValue(def storage.ColumnDef, literal string) (json.RawMessage, error) {
+	invalid := fmt.Errorf("❌ Invalid %s value '%s' for column %s", def.Type, literal, def.Name)
+	var v interface{}
+	switch def.Type {
+	case storage.ColumnInt:
+		n, err := strconv.ParseInt(literal, 10, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		v = n
+	case storage.ColumnFloat:
+		f, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		v = f
+	case storage.ColumnBool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return nil, invalid
+		}
+		v = b
+	case storage.ColumnTimestamp:
+		t, ok := parseTimestamp(literal)
+		if !ok {
+			return nil, invalid
+		}
+		v = t.UTC().Format(time.RFC3339Nano)
+	case storage.ColumnJSON:
+		if !json.Valid([]byte(literal)) {
+			return nil, invalid
+		}
+		return json.RawMessage(literal), nil
+	default:
+		v = literal
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, invalid
+	}
+	return encoded, nil
+}
+
+// decodeColumnValue converts stored JSON to the Go value of the column:
+// int, float64, bool, string, time.Time or json.RawMessage, nil for none.
+func decodeColumnValue(def storage.ColumnDef, raw json.RawMessage) interface{} {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	switch def.Type {
+	case storage.ColumnInt:
+		var n int
+		if json.Unmarshal(raw, &n) == nil {
+			return n
+		}
+	case storage.ColumnFloat:
+		var f float64
+		if json.Unmarshal(raw, &f) == nil {
+			return f
+		}
+	case storage.ColumnBool:
+		var b bool
+		if json.Unmarshal(raw, &b) == nil {
+			return b
+		}
+	case storage.ColumnTimestamp:
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			if t, ok := parseTimestamp(s); ok {
+				return t
+			}
+		}
+	case storage.ColumnJSON:
+		return raw
+	default:
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			return s
+		}
+	}
+	return nil
+}
+
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// columnValue returns the value of a user-defined column of a task,
+// falling back to the column default.
+func (s *tableSchema) columnValue(task dagdb.DAGTask, def storage.ColumnDef) interface{} {
+	if raw, ok := s.columnValues(task)[def.Name]; ok {
+		return decodeColumnValue(def, raw)
+	}
+	if def.Default != nil {
+		if raw, err := encodeColumnValue(def, *def.Default); err == nil {
+			return decodeColumnValue(def, raw)
+		}
+	}
+	return nil
+}
+
+// setColumn stores a literal as the value of a user-defined column,
+// reporting whether it changed.
+func (s *tableSchema) setColumn(task dagdb.DAGTask, def storage.ColumnDef, literal string) (bool, error) {
+	raw, err := encodeColumnValue(def, literal)
+	if err != nil {
+		return false, err
+	}
+	return s.putColumnRaw(task, def.Name, raw), nil
+}
+
+// setColumnLiteral stores a literal from VALUES or SET as the value of a
+// user-defined column, reporting whether it changed. NULL is stored as an
+// explicit null, so the column default does not apply to it.
+func (s *tableSchema) setColumnLiteral(task dagdb.DAGTask, def storage.ColumnDef, lit ast.Literal) (bool, error) {
+	if !lit.IsNull() {
+		return s.setColumn(task, def, lit.Text)
+	}
+	if def.NotNull {
+		return false, fmt.Errorf("❌ Column %s cannot be NULL", def.Name)
+	}
+	return s.putColumnRaw(task, def.Name, json.RawMessage("null")), nil
+}
+
+// nullableFields are the built-in fields that accept NULL, with the value
+// stored for it. Empty text and an empty dependency list read as NULL.
+var nullableFields = map[string]string{"status": "", "payload": "", "dependencies": "[]"}
+
+// builtinLiteral returns the text stored for a literal in a built-in field.
+func builtinLiteral(field string, lit ast.Literal) (string, error) {
+	if !lit.IsNull() {
+		return lit.Text, nil
+	}
+	value, ok := nullableFields[field]
+	if !ok {
+		return "", fmt.Errorf("❌ Field %s cannot be NULL", field)
+	}
+	return value, nil
+}
+
+// applyDefaults stores the default of every column a new task was not
+// given a value for, and rejects missing NOT NULL columns. It returns the
+// columns that got their default.
+func (s *tableSchema) applyDefaults(task dagdb.DAGTask, provided map[string]bool) ([]string, error) {
+	var defaulted []string
+	for _, def := range s.columns {
+		if provided[def.Name] {
+			continue
+		}
+		if def.Default != nil {
+			if _, err := s.setColumn(task, def, *def.Default); err != nil {
+				return nil, err
+			}
+			defaulted = append(defaulted, def.Name)
+			continue
+		}
+		if def.NotNull {
+			return nil, fmt.Errorf("❌ Missing value for NOT NULL column: %s", def.Name)
+		}
+	}
+	return defaulted, nil
+}
+
+// resultColumnType maps a user-defined column type to a result column type.
+func resultColumnType(def storage.ColumnDef) ColumnType {
+	switch def.Type {
+	case storage.ColumnInt:
+		return TypeInt
+	case storage.ColumnFloat:
+		return TypeFloat
+	case storage.ColumnBool:
+		return TypeBool
+	case storage.ColumnTimestamp:
+		return TypeTimestamp
+	case storage.ColumnJSON:
+		return TypeJSON
+	default:
+		return TypeString
+	}
+}
+
+// columnText renders a column value as text.
+func columnText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case json.RawMessage:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -2,245 +2,227 @@ package executor
 
 import (
 	"fmt"
-	"regexp"
 	"sort"
 	"strings"
 
 	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/ast"
-
-	"github.com/olekukonko/tablewriter"
+	"dagenie/internal/dql/storage"
 )
 
-// Regex to detect aggregate functions like SUM(duration)
-var aggregateRegex = regexp.MustCompile(`(?i)(sum|avg|max|min|count)\s*\(\s*([a-zA-Z0-9_*]+)\s*\)`)
-
-func ExecuteSelect(db *dagdb.DAGDB, selectAST *ast.SelectQueryAST) (string, error) {
-	fmt.Println("S1")
-	if selectAST.Table != "dag" {
-		return "", fmt.Errorf("❌ Unsupported table: %s", selectAST.Table)
+// ExecuteSelect runs a SELECT or PATH query.
+func ExecuteSelect(database *storage.Database, tx *Transaction, selectAST *ast.SelectQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, selectAST.Table)
+	if err != nil {
+		return nil, err
 	}
-
-	validFields := map[string]bool{
-		"_id": true, "dagid": true, "id": true, "name": true,
-		"status": true, "payload": true, "dependencies": true,
-		"duration": true, "retries": true,
+	schema, err := loadSchema(database, tx, selectAST.Table)
+	if err != nil {
+		return nil, err
 	}
 
 	// Expand SELECT *
 	fields := selectAST.Fields
 	if len(fields) == 1 && fields[0] == "*" {
-		fields = []string{"id", "name", "status", "payload", "dependencies", "dagid", "duration", "retries", "_id"}
+		fields = schema.allFields()
+		if selectAST.OrderByTopo {
+			fields = append(fields, "level")
+		}
 		selectAST.Fields = fields
 	}
 
-	// Validate fields (skip aggregates)
+	// Validate fields; aggregate calls are parsed into Aggregates
 	for _, field := range fields {
 		fieldLower := strings.ToLower(field)
-		if aggregateRegex.MatchString(fieldLower) {
+		if fieldLower == "level" && selectAST.OrderByTopo {
 			continue
 		}
-		if !validFields[fieldLower] {
-			return "", fmt.Errorf("❌ Unknown field: %s", field)
+		if !schema.validField(fieldLower) {
+			return nil, fmt.Errorf("❌ Unknown field: %s", field)
 		}
 	}
-	fmt.Println("START")
-
-	// Load tasks
-	var tasks []dagdb.DAGTask
-	var err error
-	if objectID, ok := selectAST.Conditions["_id"]; ok {
-		tasks, err = db.QueryByObjectID(objectID)
-	} else if dagID, ok := selectAST.Conditions["dagid"]; ok {
-		tasks, err = db.ListTasksByDAG(dagID)
-	} else {
-		tasks, err = db.ListAllTasks()
+	if err := schema.validateAggregates(selectAST); err != nil {
+		return nil, err
 	}
-	if err != nil {
-		return "", fmt.Errorf("❌ Task fetch error: %v", err)
+	if err := schema.validateWhere(selectAST.Where); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Here1")
-
-	// Filter by WHERE
-	var filtered []dagdb.DAGTask
-	for _, task := range tasks {
-		match := true
-		for condField, condVal := range selectAST.Conditions {
-			val := strings.ToLower(getField(task, condField))
-			if val != strings.ToLower(condVal) {
-				match = false
-				break
-			}
+	// Load tasks, either from a graph traversal or the whole table
+	var tasks []dagdb.DAGTask
+	if selectAST.Graph != nil {
+		tasks, err = loadGraphSourceTasks(db, selectAST.Graph)
+		if err != nil {
+			return nil, err
 		}
-		if match {
-			filtered = append(filtered, task)
+	} else {
+		tasks, err = loadCandidateTasks(db, selectAST.Where)
+		if err != nil {
+			return nil, fmt.Errorf("❌ Task fetch error: %v", err)
 		}
 	}
 
-	fmt.Println("Here2")
+	// Filter by WHERE
+	filtered := schema.filterTasks(tasks, selectAST.Where)
 
 	// COUNT(*)
 	if selectAST.IsCount && len(selectAST.Aggregates) == 0 {
-		return fmt.Sprintf("🔢 Count=%d\n\033[32m✅ Done\033[0m", len(filtered)), nil
+		return &ResultSet{
+			Columns: []Column{{Name: "COUNT(*)", Type: TypeInt}},
+			Rows:    [][]interface{}{{len(filtered)}},
+		}, nil
 	}
 
 	// Handle Aggregates
 	if len(selectAST.Aggregates) > 0 {
 		if len(selectAST.GroupBy) > 0 {
-			return executeGroupedAggregates(filtered, selectAST)
+			return schema.executeGroupedAggregates(filtered, selectAST)
 		}
-		return executeGlobalAggregates(filtered, selectAST)
+		return schema.executeGlobalAggregates(filtered, selectAST)
 	}
 
 	// ORDER BY (non-aggregates only)
 	if len(selectAST.OrderBy) > 0 {
 		sort.SliceStable(filtered, func(i, j int) bool {
 			for _, ob := range selectAST.OrderBy {
-				vi := getField(filtered[i], ob.Field)
-				vj := getField(filtered[j], ob.Field)
-				if vi == vj {
+				cmp := schema.compareTasks(filtered[i], filtered[j], ob.Field)
+				if cmp == 0 {
 					continue
 				}
 				if ob.Desc {
-					return vi > vj
+					return cmp > 0
 				}
-				return vi < vj
+				return cmp < 0
 			}
 			return false
 		})
 	}
 
+	// ORDER BY TOPOLOGICAL
+	valueOf := schema.resultValue
+	if selectAST.OrderByTopo {
+		var levels map[string]int
+		filtered, levels, err = orderTopologically(db, filtered)
+		if err != nil {
+			return nil, err
+		}
+		valueOf = func(task dagdb.DAGTask, field string) interface{} {
+			if strings.ToLower(field) == "level" {
+				return levels[taskKey(task)]
+			}
+			return schema.resultValue(task, field)
+		}
+	}
+
 	// LIMIT
 	if selectAST.Limit > 0 && len(filtered) > selectAST.Limit {
 		filtered = filtered[:selectAST.Limit]
 	}
-	fmt.Println("Here3")
 
-	// No results
-	if len(filtered) == 0 {
-		return "❌ No results", nil
-	}
-
-	fmt.Println("FINAL")
 	// Final output
-	return formatSelectResults(filtered, fields), nil
+	return &ResultSet{Columns: schema.taskColumns(fields), Rows: taskRows(filtered, fields, valueOf)}, nil
 }
 
-func formatSelectResults(tasks []dagdb.DAGTask, fields []string) string {
-	var sb strings.Builder
-
-	// Prepare headers
-	headers := []string{}
-	for _, field := range fields {
-		fieldUpper := strings.ToUpper(field)
-		if fieldUpper == "_ID" {
-			fieldUpper = "ObjectID"
+// validateAggregates rejects aggregate calls, GROUP BY fields and ORDER BY
+// aggregates on unknown fields. Only COUNT takes *, and the other
+// aggregates need a numeric field.
+func (s *tableSchema) validateAggregates(selectAST *ast.SelectQueryAST) error {
+	for _, agg := range selectAST.Aggregates {
+		if err := s.validateAggregate(agg.Func, agg.Field); err != nil {
+			return err
 		}
-		headers = append(headers, fieldUpper)
 	}
-
-	// Setup tablewriter
-	table := tablewriter.NewWriter(&sb)
-	table.SetHeader(headers)
-
-	// Color settings
-	headerColors := make([]tablewriter.Colors, len(headers))
-	colColors := make([]tablewriter.Colors, len(headers))
-	for i := range headers {
-		headerColors[i] = tablewriter.Colors{tablewriter.FgHiWhiteColor}
-		colColors[i] = tablewriter.Colors{tablewriter.FgGreenColor}
+	for _, ob := range selectAST.OrderByAgg {
+		if err := s.validateAggregate(ob.Func, ob.Field); err != nil {
+			return err
+		}
 	}
-	table.SetHeaderColor(headerColors...)
-	table.SetColumnColor(colColors...)
-	table.SetBorder(true)
-
-	// Build rows from task data
-	for _, task := range tasks {
-		row := []string{}
-		for _, field := range fields {
-			val := getField(task, field)
-			row = append(row, val)
+	for _, field := range selectAST.GroupBy {
+		if !s.validField(field) {
+			return fmt.Errorf("❌ Unknown field: %s", field)
 		}
-		table.Append(row)
 	}
-
-	table.Render()
-	sb.WriteString("\033[32m✅ Done\033[0m\n")
-	return sb.String()
+	return nil
 }
 
-func executeGlobalAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (string, error) {
-	if len(tasks) == 0 {
-		return "❌ No data to aggregate\n✅ Done", nil
+// validateAggregate checks the field of one aggregate call. A JSON path
+// expression with -> may hold numbers, so it is accepted for any
+// aggregate; its non-numeric values are skipped.
+func (s *tableSchema) validateAggregate(fn, field string) error {
+	if field == "*" {
+		if fn == "COUNT" {
+			return nil
+		}
+		return fmt.Errorf("❌ Unknown field: %s(*) needs a field", fn)
 	}
+	if !s.validField(field) {
+		return fmt.Errorf("❌ Unknown field: %s", field)
+	}
+	switch s.columnType(field) {
+	case TypeInt, TypeFloat:
+		return nil
+	case TypeJSON:
+		if _, ok := s.pathField(field); ok {
+			return nil
+		}
+	}
+	if fn == "COUNT" {
+		return nil
+	}
+	return fmt.Errorf("❌ Unknown field: %s(%s) needs a numeric field", fn, field)
+}
 
-	var sb strings.Builder
-	for _, agg := range ast.Aggregates {
-		vals := getNumericFieldValues(tasks, agg.Field)
-
-		switch agg.Func {
-		case "SUM":
-			sum := 0.0
-			for _, v := range vals {
-				sum += v
-			}
-			sb.WriteString(fmt.Sprintf("SUM(%s)=%.2f ", agg.Field, sum))
-
-		case "AVG":
-			if len(vals) == 0 {
-				sb.WriteString(fmt.Sprintf("AVG(%s)=0.00 ", agg.Field))
-			} else {
-				sum := 0.0
-				for _, v := range vals {
-					sum += v
-				}
-				avg := sum / float64(len(vals))
-				sb.WriteString(fmt.Sprintf("AVG(%s)=%.2f ", agg.Field, avg))
-			}
-
-		case "MAX":
-			if len(vals) == 0 {
-				sb.WriteString(fmt.Sprintf("MAX(%s)=N/A ", agg.Field))
-			} else {
-				max := vals[0]
-				for _, v := range vals[1:] {
-					if v > max {
-						max = v
-					}
-				}
-				sb.WriteString(fmt.Sprintf("MAX(%s)=%.2f ", agg.Field, max))
-			}
+// aggregateColumn describes the result column of an aggregate call.
+func aggregateColumn(fn, field string) Column {
+	if fn == "COUNT" {
+		return Column{Name: fmt.Sprintf("COUNT(%s)", field), Type: TypeInt}
+	}
+	return Column{Name: fmt.Sprintf("%s(%s)", fn, field), Type: TypeFloat}
+}
 
-		case "MIN":
-			if len(vals) == 0 {
-				sb.WriteString(fmt.Sprintf("MIN(%s)=N/A ", agg.Field))
-			} else {
-				min := vals[0]
-				for _, v := range vals[1:] {
-					if v < min {
-						min = v
-					}
-				}
-				sb.WriteString(fmt.Sprintf("MIN(%s)=%.2f ", agg.Field, min))
+// aggregateValue computes one aggregate over tasks. MAX and MIN of no
+// values are nil; SUM and AVG of no values are 0.
+func (s *tableSchema) aggregateValue(tasks []dagdb.DAGTask, agg ast.AggregateFunc) interface{} {
+	vals := s.getNumericFieldValues(tasks, agg.Field)
+	switch agg.Func {
+	case "SUM", "AVG":
+		sum := 0.0
+		for _, v := range vals {
+			sum += v
+		}
+		if agg.Func == "AVG" && len(vals) > 0 {
+			return sum / float64(len(vals))
+		}
+		return sum
+	case "MAX", "MIN":
+		if len(vals) == 0 {
+			return nil
+		}
+		best := vals[0]
+		for _, v := range vals[1:] {
+			if (agg.Func == "MAX" && v > best) || (agg.Func == "MIN" && v < best) {
+				best = v
 			}
-
-		case "COUNT":
-			sb.WriteString(fmt.Sprintf("COUNT(%s)=%d ", agg.Field, len(tasks)))
 		}
+		return best
+	case "COUNT":
+		return len(tasks)
+	default:
+		return nil
 	}
-
-	sb.WriteString("\n✅ Done")
-	return sb.String(), nil
 }
 
-func executeGroupedAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (string, error) {
-	if len(tasks) == 0 {
-		return "❌ No data to group\n\033[32m✅ Done\033[0m", nil
+func (s *tableSchema) executeGlobalAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (*ResultSet, error) {
+	result := &ResultSet{Rows: [][]interface{}{{}}}
+	for _, agg := range ast.Aggregates {
+		result.Columns = append(result.Columns, aggregateColumn(agg.Func, agg.Field))
+		result.Rows[0] = append(result.Rows[0], s.aggregateValue(tasks, agg))
 	}
+	return result, nil
+}
 
+func (s *tableSchema) executeGroupedAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (*ResultSet, error) {
 	type groupKey struct {
-		values []string
 		keyStr string
 	}
 
@@ -250,263 +232,93 @@ func executeGroupedAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (s
 	for _, task := range tasks {
 		vals := []string{}
 		for _, field := range ast.GroupBy {
-			fmt.Printf("2: %s", getField(task, field))
-			vals = append(vals, getField(task, field))
+			vals = append(vals, s.getField(task, field))
 		}
 		keyStr := strings.Join(vals, "||")
 		if _, exists := groupMap[keyStr]; !exists {
-			groupKeys = append(groupKeys, groupKey{values: vals, keyStr: keyStr})
+			groupKeys = append(groupKeys, groupKey{keyStr: keyStr})
 		}
 		groupMap[keyStr] = append(groupMap[keyStr], task)
 	}
 
-	// Prepare clean headers
-	headers := []string{}
-	for _, field := range ast.GroupBy {
-		headers = append(headers, strings.ToUpper(field))
-	}
+	// Group columns first, then one column per aggregate
+	result := &ResultSet{Columns: s.taskColumns(ast.GroupBy)}
 	for _, agg := range ast.Aggregates {
-		headers = append(headers, fmt.Sprintf("%s(%s)", agg.Func, strings.ToUpper(agg.Field)))
+		result.Columns = append(result.Columns, aggregateColumn(agg.Func, agg.Field))
 	}
 
-	// Prepare rows
-	var rows [][]string
 	for _, g := range groupKeys {
 		groupTasks := groupMap[g.keyStr]
-		row := make([]string, 0, len(g.values))
-		row = append(row, g.values...)
-
+		row := make([]interface{}, 0, len(result.Columns))
+		for _, field := range ast.GroupBy {
+			row = append(row, s.resultValue(groupTasks[0], field))
+		}
 		for _, agg := range ast.Aggregates {
-			vals := getNumericFieldValues(groupTasks, agg.Field)
-			switch agg.Func {
-			case "SUM":
-				sum := 0.0
-				for _, v := range vals {
-					sum += v
-				}
-				row = append(row, fmt.Sprintf("%.2f", sum))
-			case "AVG":
-				sum := 0.0
-				for _, v := range vals {
-					sum += v
-				}
-				avg := 0.0
-				if len(vals) > 0 {
-					avg = sum / float64(len(vals))
-				}
-				row = append(row, fmt.Sprintf("%.2f", avg))
-			case "MAX":
-				max := 0.0
-				if len(vals) > 0 {
-					max = vals[0]
-					for _, v := range vals[1:] {
-						if v > max {
-							max = v
-						}
-					}
-				}
-				row = append(row, fmt.Sprintf("%.2f", max))
-			case "MIN":
-				min := 0.0
-				if len(vals) > 0 {
-					min = vals[0]
-					for _, v := range vals[1:] {
-						if v < min {
-							min = v
-						}
-					}
-				}
-				row = append(row, fmt.Sprintf("%.2f", min))
-			case "COUNT":
-				row = append(row, fmt.Sprintf("%d", len(groupTasks)))
-			}
+			row = append(row, s.aggregateValue(groupTasks, agg))
 		}
-		rows = append(rows, row)
+		result.Rows = append(result.Rows, row)
 	}
 
 	// ORDER BY Aggregate
 	if len(ast.OrderByAgg) > 0 {
+		rows := result.Rows
 		sort.SliceStable(rows, func(i, j int) bool {
 			for _, ob := range ast.OrderByAgg {
-				targetHeader := fmt.Sprintf("%s(%s)", ob.Func, strings.ToUpper(ob.Field))
+				target := aggregateColumn(ob.Func, ob.Field).Name
 
 				colIndex := -1
-				for idx, hdr := range headers {
-					if strings.EqualFold(hdr, targetHeader) {
+				for idx, col := range result.Columns {
+					if strings.EqualFold(col.Name, target) {
 						colIndex = idx
 						break
 					}
 				}
-
-				if colIndex == -1 || colIndex >= len(rows[i]) || colIndex >= len(rows[j]) {
+				if colIndex == -1 {
 					continue
 				}
 
-				vi := rows[i][colIndex]
-				vj := rows[j][colIndex]
-				var viFloat, vjFloat float64
-				_, err1 := fmt.Sscanf(vi, "%f", &viFloat)
-				_, err2 := fmt.Sscanf(vj, "%f", &vjFloat)
-				if err1 != nil || err2 != nil {
-					continue
-				}
-				if viFloat == vjFloat {
+				vi, ok1 := numericValue(rows[i][colIndex])
+				vj, ok2 := numericValue(rows[j][colIndex])
+				if !ok1 || !ok2 || vi == vj {
 					continue
 				}
 				if ob.Desc {
-					return viFloat > vjFloat
+					return vi > vj
 				}
-				return viFloat < vjFloat
+				return vi < vj
 			}
 			return false
 		})
 	}
 
 	// LIMIT
-	if ast.Limit > 0 && len(rows) > ast.Limit {
-		rows = rows[:ast.Limit]
+	if ast.Limit > 0 && len(result.Rows) > ast.Limit {
+		result.Rows = result.Rows[:ast.Limit]
 	}
 
-	// Render table with colored fields
-	var sb strings.Builder
-	table := tablewriter.NewWriter(&sb)
-	table.SetHeader(headers)
-
-	// Add color: white headers, green fields
-	headerColors := make([]tablewriter.Colors, len(headers))
-	colColors := make([]tablewriter.Colors, len(headers))
-	for i := range headers {
-		headerColors[i] = tablewriter.Colors{tablewriter.FgHiWhiteColor}
-		colColors[i] = tablewriter.Colors{tablewriter.FgGreenColor}
-	}
-	table.SetHeaderColor(headerColors...)
-	table.SetColumnColor(colColors...)
+	return result, nil
+}
 
-	for _, row := range rows {
-		table.Append(row)
+// numericValue reads an aggregate result as a float.
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
 	}
-	table.SetBorder(true)
-	table.Render()
-
-	sb.WriteString("\033[32m✅ Done\033[0m\n")
-	return sb.String(), nil
 }
 
-/*
-	func executeGroupedAggregates(tasks []dagdb.DAGTask, ast *ast.SelectQueryAST) (string, error) {
-		if len(tasks) == 0 {
-			return "❌ No data to group\n✅ Done", nil
-		}
-
-		// Group tasks
-		groupMap := make(map[string][]dagdb.DAGTask)
-		var groupKeys []string
-
-		for _, task := range tasks {
-			keyParts := []string{}
-			for _, field := range ast.GroupBy {
-				keyParts = append(keyParts, getField(task, field))
-			}
-			key := strings.Join(keyParts, "_")
-			groupMap[key] = append(groupMap[key], task)
-			if !contains(groupKeys, key) {
-				groupKeys = append(groupKeys, key)
-			}
-		}
-
-		// Prepare table headers
-		headers := append(ast.GroupBy, []string{}...) // Group fields
-		for _, agg := range ast.Aggregates {
-			headers = append(headers, fmt.Sprintf("%s(%s)", agg.Func, agg.Field))
-		}
-
-		// Prepare table data
-		var rows [][]string
-		for _, key := range groupKeys {
-			groupTasks := groupMap[key]
-			keyParts := strings.Split(key, "_")
-			row := append([]string{}, keyParts...) // Group field values
-
-			for _, agg := range ast.Aggregates {
-				vals := getNumericFieldValues(groupTasks, agg.Field)
-				switch agg.Func {
-				case "SUM":
-					sum := 0.0
-					for _, v := range vals {
-						sum += v
-					}
-					row = append(row, fmt.Sprintf("%.2f", sum))
-				case "AVG":
-					sum := 0.0
-					for _, v := range vals {
-						sum += v
-					}
-					avg := 0.0
-					if len(vals) > 0 {
-						avg = sum / float64(len(vals))
-					}
-					row = append(row, fmt.Sprintf("%.2f", avg))
-				case "MAX":
-					max := 0.0
-					if len(vals) > 0 {
-						max = vals[0]
-						for _, v := range vals[1:] {
-							if v > max {
-								max = v
-							}
-						}
-					}
-					row = append(row, fmt.Sprintf("%.2f", max))
-				case "MIN":
-					min := 0.0
-					if len(vals) > 0 {
-						min = vals[0]
-						for _, v := range vals[1:] {
-							if v < min {
-								min = v
-							}
-						}
-					}
-					row = append(row, fmt.Sprintf("%.2f", min))
-				case "COUNT":
-					row = append(row, fmt.Sprintf("%d", len(groupTasks)))
-				}
-			}
-			rows = append(rows, row)
-		}
-
-		// Build table as string
-		var sb strings.Builder
-		table := tablewriter.NewWriter(&sb)
-		table.SetHeader(headers)
-		for _, row := range rows {
-			table.Append(row)
-		}
-		table.SetBorder(true)
-		table.Render()
-
-		sb.WriteString("\033[32m✅ Done\033[0m\n")
-		return sb.String(), nil
-	}
-*/
-func getNumericFieldValues(tasks []dagdb.DAGTask, field string) []float64 {
+// getNumericFieldValues collects the numeric values of a field, skipping
+// tasks without one.
+func (s *tableSchema) getNumericFieldValues(tasks []dagdb.DAGTask, field string) []float64 {
 	var values []float64
 	for _, task := range tasks {
-		switch strings.ToLower(field) {
-		case "duration":
-			values = append(values, float64(task.Duration))
-		case "retries":
-			values = append(values, float64(task.Retries))
+		if v, ok := numericValue(s.fieldValue(task, field)); ok {
+			values = append(values, v)
 		}
 	}
 	return values
 }
-
-func contains(slice []string, val string) bool {
-	for _, s := range slice {
-		if s == val {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecuteCreateTable runs CREATE TABLE.
+func ExecuteCreateTable(database *storage.Database, createAST *ast.CreateTableAST) (*ResultSet, error) {
+	var columns []storage.ColumnDef
+	seen := make(map[string]bool)
+	for _, col := range createAST.Columns {
+		def, err := columnDefinition(col)
+		if err != nil {
+			return nil, err
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("❌ Duplicate column: %s", def.Name)
+		}
+		seen[def.Name] = true
+		columns = append(columns, def)
+	}
+	if err := database.CreateTable(createAST.Name, columns); err != nil {
+		return nil, err
+	}
+	return statusResult(0, "✅ Table '%s' created", createAST.Name), nil
+}
+
+// ExecuteAlterTable runs ALTER TABLE ADD COLUMN and DROP COLUMN. Dropping
+// a column deletes its stored values, so a column added again under the
+// same name does not get them back.
+func ExecuteAlterTable(database *storage.Database, alterAST *ast.AlterTableAST) (*ResultSet, error) {
+	if _, err := database.Table(alterAST.Name); err != nil {
+		return nil, err
+	}
+
+	if alterAST.Action == "DROP" {
+		if err := database.DropColumn(alterAST.Name, alterAST.Column.Name); err != nil {
+			return nil, err
+		}
+		return statusResult(0, "🗑️ Column '%s' dropped from table '%s'", alterAST.Column.Name, alterAST.Name), nil
+	}
+
+	def, err := columnDefinition(alterAST.Column)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.AddColumn(alterAST.Name, def); err != nil {
+		return nil, err
+	}
+	return statusResult(0, "✅ Column '%s' added to table '%s'", def.Name, alterAST.Name), nil
+}
+
+// columnDefinition checks a parsed column definition: the type must be
+// known, the name must not shadow a built-in field and the default must
+// be a valid value of the type.
+func columnDefinition(col ast.ColumnDef) (storage.ColumnDef, error) {
+	colType, err := storage.ValidateColumnType(col.Type)
+	if err != nil {
+		return storage.ColumnDef{}, err
+	}
+	name := strings.ToLower(col.Name)
+	if validFields[name] || name == "level" {
+		return storage.ColumnDef{}, fmt.Errorf("❌ Column name '%s' is a built-in field", col.Name)
+	}
+	def := storage.ColumnDef{Name: name, Type: colType, NotNull: col.NotNull, Default: col.Default}
+	if def.Default != nil {
+		if _, err := encodeColumnValue(def, *def.Default); err != nil {
+			return storage.ColumnDef{}, err
+		}
+	}
+	return def, nil
+}
+
+// ExecuteDropTable runs DROP TABLE.
+func ExecuteDropTable(database *storage.Database, dropAST *ast.DropTableAST) (*ResultSet, error) {
+	if err := database.DropTable(dropAST.Name); err != nil {
+		return nil, err
+	}
+	return statusResult(0, "🗑️ Table '%s' dropped", dropAST.Name), nil
+}
+
+// ExecuteShowTables lists the tables of the database with their task counts,
+// as a statement in tx sees them, and user-defined columns.
+func ExecuteShowTables(database *storage.Database, tx *Transaction) (*ResultSet, error) {
+	result := &ResultSet{Columns: []Column{
+		{Name: "table", Type: TypeString},
+		{Name: "created_at", Type: TypeString},
+		{Name: "tasks", Type: TypeInt},
+		{Name: "columns", Type: TypeString},
+	}}
+	for _, info := range database.Tables() {
+		db, err := openTable(database, tx, info.Name)
+		if err != nil {
+			return nil, err
+		}
+		tasks, err := db.ListAllTasks()
+		if err != nil {
+			return nil, fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		var createdAt interface{}
+		if !info.CreatedAt.IsZero() {
+			createdAt = info.CreatedAt.Format(time.RFC3339)
+		}
+		result.Rows = append(result.Rows, []interface{}{info.Name, createdAt, len(tasks), describeColumns(info.Columns)})
+	}
+	return result, nil
+}
+
+// describeColumns renders column definitions the way CREATE TABLE takes
+// them.
+func describeColumns(columns []storage.ColumnDef) string {
+	parts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		part := col.Name + " " + col.Type
+		if col.NotNull {
+			part += " NOT NULL"
+		}
+		if col.Default != nil {
+			part += fmt.Sprintf(" DEFAULT '%s'", *col.Default)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,179 @@
+package executor
+
+import (
+	"dagenie/internal/dql/storage"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Transaction groups the writes of one or more statements so that they
+// are applied together or not at all: either an explicit BEGIN ... COMMIT
+// block of a session, or the implicit transaction of a single write
+// statement.
+//
+// The first write takes the database's writer lock, which is held until
+// Commit or Rollback, so no other statement writes in between. Writes are
+// held in the transaction until Commit: its own statements see them,
+// other sessions do not. Commit applies them in one journaled batch and
+// then updates the in-memory graphs; a commit cut short by a crash is
+// undone from the journal when the database is opened again. This is not
+// a Badger transaction: DAGDB exposes none, so the journal and the
+// commit lock provide the atomicity instead (see storage.Database.Commit).
+//
+// Between the statements of a session, Suspend starts an idle timer. A
+// transaction left idle for too long is rolled back and releases the
+// writer lock, so an abandoned BEGIN does not block other writers.
+type Transaction struct {
+	database *storage.Database
+	writes   writeSet
+	locked   bool
+
+	// guard the state below against the idle timer
+	mu      sync.Mutex
+	idle    *time.Timer
+	pauses  int
+	expired time.Duration
+}
+
+// BeginTransaction starts a transaction on a database.
+func BeginTransaction(database *storage.Database) *Transaction {
+	return &Transaction{database: database, writes: writeSet{database: database}}
+}
+
+// lock takes the writer lock before the first write.
+func (t *Transaction) lock() error {
+	if t.locked {
+		return nil
+	}
+	if err := t.database.LockWriter(); err != nil {
+		return err
+	}
+	t.locked = true
+	return nil
+}
+
+func (t *Transaction) unlock() {
+	if t.locked {
+		t.database.UnlockWriter()
+		t.locked = false
+	}
+}
+
+// Writes is the number of task writes made so far.
+func (t *Transaction) Writes() int {
+	return t.writes.len()
+}
+
+// Commit applies the writes and updates the in-memory graphs. If a write
+// fails, nothing is applied.
+func (t *Transaction) Commit() error {
+	defer t.unlock()
+	if err := t.writes.commit(); err != nil {
+		return fmt.Errorf("❌ Commit failed, transaction rolled back: %v", err)
+	}
+	return nil
+}
+
+// Rollback drops every write of the transaction.
+func (t *Transaction) Rollback() {
+	t.writes.rollback()
+	t.unlock()
+}
+
+// Savepoint marks the writes made so far, for RollbackTo.
+func (t *Transaction) Savepoint() int {
+	return t.writes.len()
+}
+
+// RollbackTo drops the writes made since savepoint, such as those of a
+// statement that failed, and keeps the transaction open.
+func (t *Transaction) RollbackTo(savepoint int) {
+	t.writes.truncate(savepoint)
+}
+
+// Suspend starts the idle timer of the transaction: if Resume is not
+// called within timeout, the transaction is rolled back. A timeout of 0
+// disables the timer.
+func (t *Transaction) Suspend(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pauses++
+	pause := t.pauses
+	t.idle = time.AfterFunc(timeout, func() {
+		t.expire(pause, timeout)
+	})
+}
+
+// Resume stops the idle timer before the next statement of the
+// transaction. It fails if the transaction was rolled back meanwhile.
+func (t *Transaction) Resume() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.idle != nil {
+		t.idle.Stop()
+		t.idle = nil
+	}
+	if t.expired > 0 {
+		return fmt.Errorf("❌ Transaction rolled back after being idle for %s", t.expired)
+	}
+	return nil
+}
+
+// expire rolls the transaction back when its idle timer fires, unless it
+// was resumed meanwhile.
+func (t *Transaction) expire(pause int, timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.idle == nil || t.pauses != pause {
+		return
+	}
+	t.idle = nil
+	t.expired = timeout
+	t.Rollback()
+	fmt.Printf("⏱️ Rolled back a transaction idle for %s\n", timeout)
+}
+
+// openTable returns a table as a statement in tx sees it, with the writes
+// tx made so far, or as committed when tx is nil. The statement executors
+// take the transaction they run in, nil outside one, and read through
+// openTable and loadSchema, so they all see the writes of their own
+// transaction.
+func openTable(database *storage.Database, tx *Transaction, name string) (*storage.Table, error) {
+	if tx == nil {
+		return database.Table(name)
+	}
+	return database.View(name, tx.writes.writes)
+}
+
+// runWrite runs a write statement inside tx, or inside its own
+// transaction when tx is nil, which is committed if the statement
+// succeeds and rolled back if it fails. A failure inside tx is left to
+// the owner of tx.
+func runWrite(database *storage.Database, tx *Transaction, statement func(tx *Transaction) (*ResultSet, error)) (*ResultSet, error) {
+	own := tx == nil
+	if own {
+		tx = BeginTransaction(database)
+	}
+	if err := tx.lock(); err != nil {
+		return nil, err
+	}
+	result, err := statement(tx)
+	if !own {
+		return result, err
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -3,141 +3,225 @@ package executor
 import (
 	"dagenie/internal/dagdb"
 	"dagenie/internal/dql/ast"
+	"dagenie/internal/dql/storage"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
 
-func ExecuteUpdate(db *dagdb.DAGDB, updateAST *ast.UpdateQueryAST) (string, error) {
-	if updateAST.Table != "dag" {
-		return "", fmt.Errorf("❌ Unsupported table: %s", updateAST.Table)
+// ExecuteUpdate applies a SET list to the tasks matching WHERE, in tx, or
+// in a transaction of its own when tx is nil. Either every matching task
+// is updated or, if one write fails, none is. With RETURNING, the result
+// has the listed fields of every updated task, as updated.
+func ExecuteUpdate(database *storage.Database, tx *Transaction, updateAST *ast.UpdateQueryAST) (*ResultSet, error) {
+	return runWrite(database, tx, func(tx *Transaction) (*ResultSet, error) {
+		return executeUpdate(database, tx, updateAST)
+	})
+}
+
+func executeUpdate(database *storage.Database, tx *Transaction, updateAST *ast.UpdateQueryAST) (*ResultSet, error) {
+	db, err := openTable(database, tx, updateAST.Table)
+	if err != nil {
+		return nil, err
 	}
 
-	// Load all tasks into memory
-	tasks, err := db.ListAllTasks()
+	schema, err := loadSchema(database, tx, updateAST.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := schema.validateSetFields(updateAST.SetFields); err != nil {
+		return nil, err
+	}
+	if err := schema.validateWhere(updateAST.Where); err != nil {
+		return nil, err
+	}
+	returning, err := schema.returningFields(updateAST.Returning)
 	if err != nil {
-		return "", fmt.Errorf("❌ Task load error: %v", err)
+		return nil, err
 	}
 
-	updatedCount := 0
+	// Load candidate tasks into memory
+	tasks, err := loadCandidateTasks(db, updateAST.Where)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Task load error: %v", err)
+	}
 
-	for _, task := range tasks {
-		// Check WHERE condition
-		if !matchesCondition(task, updateAST.Where) {
+	// Compute every change first so the statement is validated as a whole
+	var changes []taskChange
+	var removed, saved []dagdb.DAGTask
+	for _, task := range schema.filterTasks(tasks, updateAST.Where) {
+		oldTask := task // For key comparison
+		updated, err := schema.applySetFields(&task, updateAST.SetFields)
+		if err != nil {
+			return nil, err
+		}
+		if !updated {
 			continue
 		}
+		changes = append(changes, taskChange{old: oldTask, new: task})
 
-		oldTask := task // For key comparison
-		updated := false
+		keyChanged := task.ID != oldTask.ID || task.DAGID != oldTask.DAGID
+		if keyChanged {
+			removed = append(removed, oldTask)
+		}
+		if keyChanged || !equalStrings(task.Dependencies, oldTask.Dependencies) {
+			saved = append(saved, task)
+		}
+	}
 
-		// Apply SET clause
-		for field, value := range updateAST.SetFields {
-			switch strings.ToLower(field) {
-			case "id":
-				if task.ID != value {
-					task.ID = value
-					updated = true
-				}
-			case "dagid":
-				if task.DAGID != value {
-					task.DAGID = value
-					updated = true
-				}
-			case "name":
-				if task.Name != value {
-					task.Name = value
-					updated = true
-				}
-			case "status":
-				if task.Status != value {
-					task.Status = value
-					updated = true
-				}
-			case "payload":
-				if task.Payload != value {
-					task.Payload = value
-					updated = true
-				}
-			case "duration":
-				dur, err := strconv.Atoi(value)
-				if err != nil {
-					return "", fmt.Errorf("❌ Invalid duration value: %v", err)
-				}
-				if task.Duration != dur {
-					task.Duration = dur
-					updated = true
-				}
-			case "retries":
-				ret, err := strconv.Atoi(value)
-				if err != nil {
-					return "", fmt.Errorf("❌ Invalid retries value: %v", err)
-				}
-				if task.Retries != ret {
-					task.Retries = ret
-					updated = true
-				}
-			default:
-				return "", fmt.Errorf("❌ Unknown field: %s", field)
-			}
+	// Key and dependency changes must keep every DAG acyclic and complete
+	if len(saved) > 0 || len(removed) > 0 {
+		if err := validateDAGWrites(db, removed, saved); err != nil {
+			return nil, err
 		}
+	}
 
-		if updated {
-			if task.ID != oldTask.ID || task.DAGID != oldTask.DAGID {
-				// Key changed → migrate key
-				err := db.UpdateTaskWithKeyChange(oldTask, task)
-				if err != nil {
-					return "", fmt.Errorf("❌ Key migration error: %v", err)
-				}
-			} else {
-				// Update task in DB and graph
-				err := db.SaveTask(task)
-				if err != nil {
-					return "", fmt.Errorf("❌ Save error: %v", err)
-				}
-			}
-			// Always update the graph structure
-			db.UpdateGraphTask(&task)
-			updatedCount++
+	updatedCount := 0
+	var updated []dagdb.DAGTask
+	for _, change := range changes {
+		task := change.new
+		if task.ID != change.old.ID || task.DAGID != change.old.DAGID {
+			// Key changed → migrate key
+			tx.writes.rekey(schema, change.old, task)
+		} else {
+			// Update task in DB; the graph follows on commit
+			tx.writes.update(schema, change.old, task)
 		}
+		updated = append(updated, task)
+		updatedCount++
 	}
 
 	if updatedCount == 0 {
-		return "❌ No matching tasks found", nil
+		return schema.withReturning(statusResult(0, "❌ No matching tasks found"), returning, nil), nil
 	}
 
-	return fmt.Sprintf("✅ Updated %d task(s)", updatedCount), nil
+	return schema.withReturning(statusResult(updatedCount, "✅ Updated %d task(s)", updatedCount), returning, updated), nil
 }
 
-// matchesCondition checks WHERE clause against task fields
-func matchesCondition(task dagdb.DAGTask, where map[string]string) bool {
-	for field, expected := range where {
-		switch strings.ToLower(field) {
-		case "dagid":
-			if strings.ToLower(task.DAGID) != strings.ToLower(expected) {
-				return false
+// validateSetFields rejects a SET list assigning to a field that is not a
+// column or a writable built-in field, before any task is loaded, so the
+// error does not depend on what the WHERE clause matches.
+func (s *tableSchema) validateSetFields(setFields []ast.Assignment) error {
+	for _, set := range setFields {
+		if _, ok := s.column(set.Field); !ok && !slices.Contains(coreInsertFields, strings.ToLower(set.Field)) {
+			return fmt.Errorf("❌ Unknown field: %s", set.Field)
+		}
+		if set.Value.Kind == ast.LiteralJSONSet && !s.jsonField(set.Field) {
+			return fmt.Errorf("❌ JSON_SET can only be assigned to payload or a json column, not %s", set.Field)
+		}
+	}
+	return nil
+}
+
+// applySetFields applies a SET clause to a task in statement order,
+// reporting whether any field actually changed. JSON_SET calls see the
+// task as it was before the clause, so they are all evaluated first.
+func (s *tableSchema) applySetFields(task *dagdb.DAGTask, setFields []ast.Assignment) (bool, error) {
+	values := make([]ast.Literal, len(setFields))
+	for i, set := range setFields {
+		values[i] = set.Value
+		if set.Value.Kind != ast.LiteralJSONSet {
+			continue
+		}
+		if !s.jsonField(set.Field) {
+			return false, fmt.Errorf("❌ JSON_SET can only be assigned to payload or a json column, not %s", set.Field)
+		}
+		doc, err := s.evalJSONSet(*task, set.Value.Set)
+		if err != nil {
+			return false, err
+		}
+		values[i] = ast.Literal{Kind: ast.LiteralJSON, Text: doc}
+	}
+
+	updated := false
+	for i, set := range setFields {
+		field, lit := set.Field, values[i]
+		if def, ok := s.column(field); ok {
+			changed, err := s.setColumnLiteral(*task, def, lit)
+			if err != nil {
+				return false, err
 			}
+			if changed {
+				updated = true
+			}
+			continue
+		}
+		if !validFields[strings.ToLower(field)] {
+			return false, fmt.Errorf("❌ Unknown field: %s", field)
+		}
+		value, err := builtinLiteral(strings.ToLower(field), lit)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(field) {
 		case "id":
-			if strings.ToLower(task.ID) != strings.ToLower(expected) {
-				return false
+			if task.ID != value {
+				task.ID = value
+				updated = true
+			}
+		case "dagid":
+			if task.DAGID != value {
+				task.DAGID = value
+				updated = true
 			}
 		case "name":
-			if strings.ToLower(task.Name) != strings.ToLower(expected) {
-				return false
+			if task.Name != value {
+				task.Name = value
+				updated = true
 			}
 		case "status":
-			if strings.ToLower(task.Status) != strings.ToLower(expected) {
-				return false
+			if task.Status != value {
+				task.Status = value
+				updated = true
 			}
 		case "payload":
-			if strings.ToLower(task.Payload) != strings.ToLower(expected) {
-				return false
+			if task.Payload != value {
+				task.Payload = value
+				updated = true
+			}
+		case "duration":
+			dur, err := strconv.Atoi(value)
+			if err != nil {
+				return false, fmt.Errorf("❌ Invalid duration value: %v", err)
+			}
+			if task.Duration != dur {
+				task.Duration = dur
+				updated = true
+			}
+		case "retries":
+			ret, err := strconv.Atoi(value)
+			if err != nil {
+				return false, fmt.Errorf("❌ Invalid retries value: %v", err)
+			}
+			if task.Retries != ret {
+				task.Retries = ret
+				updated = true
 			}
-		case "_id":
-			if strings.ToLower(task.ObjectID) != strings.ToLower(expected) {
-				return false
+		case "dependencies":
+			deps, err := parseDependencies(value)
+			if err != nil {
+				return false, err
+			}
+			if !equalStrings(task.Dependencies, deps) {
+				task.Dependencies = deps
+				updated = true
 			}
 		default:
-			// Unknown fields are ignored here
+			return false, fmt.Errorf("❌ Unknown field: %s", field)
+		}
+	}
+	return updated, nil
+}
+
+// equalStrings reports whether two string slices hold the same elements in
+// the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
 			return false
 		}
 	}
@@ -0,0 +1,102 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/storage"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// parseDependencies parses a JSON array of task IDs, as written in INSERT
+// and UPDATE SET dependencies = '[...]'.
+func parseDependencies(value string) ([]string, error) {
+	var dependencies []string
+	if err := json.Unmarshal([]byte(value), &dependencies); err != nil {
+		return nil, fmt.Errorf("❌ Invalid dependencies format: %v", err)
+	}
+	return dependencies, nil
+}
+
+// validateDAGWrites checks that removing the tasks in removed and then
+// saving the tasks in saved leaves every affected DAG acyclic and without
+// dangling dependencies: saved tasks may only depend on tasks of their own
+// DAG, and no remaining task may depend on a task that goes away.
+func validateDAGWrites(db *storage.Table, removed, saved []dagdb.DAGTask) error {
+	dagIDs := map[string]bool{}
+	for _, task := range removed {
+		dagIDs[task.DAGID] = true
+	}
+	for _, task := range saved {
+		dagIDs[task.DAGID] = true
+	}
+
+	sortedIDs := make([]string, 0, len(dagIDs))
+	for dagID := range dagIDs {
+		sortedIDs = append(sortedIDs, dagID)
+	}
+	sort.Strings(sortedIDs)
+
+	for _, dagID := range sortedIDs {
+		current, err := db.ListTasksByDAG(dagID)
+		if err != nil {
+			return fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		if err := validateDAGState(dagID, current, removed, saved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDAGState applies removed and saved to the current tasks of one DAG
+// and validates the result. See validateDAGWrites.
+func validateDAGState(dagID string, current, removed, saved []dagdb.DAGTask) error {
+	before := make(map[string]bool, len(current))
+	after := make(map[string]dagdb.DAGTask, len(current))
+	for _, task := range current {
+		before[task.ID] = true
+		after[task.ID] = task
+	}
+	for _, task := range removed {
+		if task.DAGID == dagID {
+			delete(after, task.ID)
+		}
+	}
+	touched := map[string]bool{}
+	for _, task := range saved {
+		if task.DAGID != dagID {
+			continue
+		}
+		if existing, ok := after[task.ID]; (ok && existing.ObjectID != task.ObjectID) || touched[task.ID] {
+			return fmt.Errorf("❌ Task '%s' already exists in DAG '%s'", task.ID, dagID)
+		}
+		after[task.ID] = task
+		touched[task.ID] = true
+	}
+
+	tasks := make([]dagdb.DAGTask, 0, len(after))
+	for _, task := range after {
+		tasks = append(tasks, task)
+	}
+	g := newTaskGraph(tasks)
+
+	for _, id := range g.ids {
+		for _, dep := range g.tasks[id].Dependencies {
+			if _, ok := after[dep]; ok {
+				continue
+			}
+			if touched[id] {
+				return fmt.Errorf("❌ Task '%s' depends on missing task '%s' in DAG '%s'", id, dep, dagID)
+			}
+			if before[dep] {
+				return fmt.Errorf("❌ Task '%s' is still a dependency of task '%s' in DAG '%s'", dep, id, dagID)
+			}
+		}
+	}
+
+	if g.findCycle() != nil {
+		return g.cycleError()
+	}
+	return nil
+}
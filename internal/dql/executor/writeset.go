@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"dagenie/internal/dagdb"
+	"dagenie/internal/dql/storage"
+)
+
+// writeSet holds the task writes of a transaction until it commits. The
+// statements of the transaction read through them (see openTable); other
+// sessions only see them once commit has applied them.
+type writeSet struct {
+	database *storage.Database
+	writes   []storage.TaskWrite
+}
+
+// add records task writes, in order.
+func (w *writeSet) add(writes ...storage.TaskWrite) {
+	w.writes = append(w.writes, writes...)
+}
+
+// insert saves a new task with the column values s holds for it.
+func (w *writeSet) insert(s *tableSchema, task dagdb.DAGTask) {
+	w.add(insertWrite(s, task))
+}
+
+// update replaces a stored task with a new version under the same key.
+func (w *writeSet) update(s *tableSchema, old, task dagdb.DAGTask) {
+	w.add(updateWrite(s, old, task))
+}
+
+// rekey replaces a stored task with a new version under a new key.
+func (w *writeSet) rekey(s *tableSchema, old, task dagdb.DAGTask) {
+	w.add(storage.TaskWrite{Table: s.table, Kind: storage.WriteRekey, Old: old, New: task,
+		OldColumns: s.stored[old.ObjectID], NewColumns: s.columnValues(task)})
+}
+
+// delete removes a stored task and its column values.
+func (w *writeSet) delete(s *tableSchema, task dagdb.DAGTask) {
+	w.add(storage.TaskWrite{Table: s.table, Kind: storage.WriteDelete, Old: task, OldColumns: s.stored[task.ObjectID]})
+}
+
+// insertWrite is the write saving a new task.
+func insertWrite(s *tableSchema, task dagdb.DAGTask) storage.TaskWrite {
+	return storage.TaskWrite{Table: s.table, Kind: storage.WriteInsert, New: task, NewColumns: s.columnValues(task)}
+}
+
+// updateWrite is the write replacing a stored task under the same key.
+func updateWrite(s *tableSchema, old, task dagdb.DAGTask) storage.TaskWrite {
+	return storage.TaskWrite{Table: s.table, Kind: storage.WriteUpdate, Old: old, New: task,
+		OldColumns: s.stored[old.ObjectID], NewColumns: s.columnValues(task)}
+}
+
+// len is the number of recorded writes.
+func (w *writeSet) len() int {
+	return len(w.writes)
+}
+
+// truncate forgets the writes recorded after the first n.
+func (w *writeSet) truncate(n int) {
+	if n < len(w.writes) {
+		w.writes = w.writes[:n]
+	}
+}
+
+// commit applies the writes as one batch; see storage.Database.Commit.
+func (w *writeSet) commit() error {
+	writes := w.writes
+	w.writes = nil
+	return w.database.Commit(writes)
+}
+
+// rollback forgets the writes.
+func (w *writeSet) rollback() {
+	w.writes = nil
+}
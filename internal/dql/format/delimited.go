@@ -0,0 +1,42 @@
+package format
+
+import (
+	"encoding/csv"
+	"io"
+
+	"dagenie/internal/dql/executor"
+)
+
+// Delimited renders a header line of column names followed by one line per
+// row, separated by Comma: ',' for CSV and '\t' for TSV. NULL values are
+// empty fields. A result without rows prints its status as a single row.
+type Delimited struct {
+	Comma rune
+}
+
+func (d Delimited) Format(w io.Writer, rs *executor.ResultSet) error {
+	columns, rows := tabular(rs)
+
+	cw := csv.NewWriter(w)
+	cw.Comma = d.Comma
+
+	header := make([]string, 0, len(columns))
+	for _, col := range columns {
+		header = append(header, col.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, 0, len(row))
+		for i, v := range row {
+			record = append(record, Text(v, columns[i].Type))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,116 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dagenie/internal/dql/executor"
+)
+
+// Formatter renders a ResultSet to a writer.
+type Formatter interface {
+	Format(w io.Writer, rs *executor.ResultSet) error
+}
+
+// DefaultFormat is the formatter used when none is selected.
+const DefaultFormat = "table"
+
+// formatters holds the registered formatters by name. Register is meant to
+// be called from init functions, before any formatting happens.
+var formatters = map[string]Formatter{
+	"table":  Table{},
+	"json":   JSON{},
+	"ndjson": NDJSON{},
+	"csv":    Delimited{Comma: ','},
+	"tsv":    Delimited{Comma: '\t'},
+}
+
+// Register adds or replaces a formatter under the given name.
+func Register(name string, f Formatter) {
+	formatters[strings.ToLower(name)] = f
+}
+
+// Lookup returns the formatter registered under name.
+func Lookup(name string) (Formatter, error) {
+	f, ok := formatters[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("❌ Unknown format '%s' (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return f, nil
+}
+
+// Names lists the registered formatter names in sorted order.
+func Names() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render formats rs with the named formatter and returns the output.
+func Render(name string, rs *executor.ResultSet) (string, error) {
+	f, err := Lookup(name)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := f.Format(&sb, rs); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Text renders one value as plain text. Values decoded from the wire keep
+// their column type: whole numbers in int columns print without decimals.
+func Text(v interface{}, typ executor.ColumnType) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.RawMessage:
+		return string(x)
+	case bool:
+		return strconv.FormatBool(x)
+	case int:
+		return strconv.Itoa(x)
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		if typ == executor.TypeInt {
+			return strconv.FormatInt(int64(x), 10)
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	default:
+		encoded, err := json.Marshal(x)
+		if err != nil {
+			return fmt.Sprintf("%v", x)
+		}
+		return string(encoded)
+	}
+}
+
+// statusColumns and statusRow describe a result without rows, such as an
+// INSERT, for the row-oriented formats.
+var statusColumns = []executor.Column{
+	{Name: "rows_affected", Type: executor.TypeInt},
+	{Name: "message", Type: executor.TypeString},
+}
+
+func statusRow(rs *executor.ResultSet) []interface{} {
+	return []interface{}{rs.RowsAffected, rs.Message}
+}
+
+// tabular returns the columns and rows a row-oriented format should print.
+func tabular(rs *executor.ResultSet) ([]executor.Column, [][]interface{}) {
+	if len(rs.Columns) == 0 {
+		return statusColumns, [][]interface{}{statusRow(rs)}
+	}
+	return rs.Columns, rs.Rows
+}
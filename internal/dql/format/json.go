@@ -0,0 +1,99 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"dagenie/internal/dql/executor"
+)
+
+// JSON renders the whole result as one JSON document:
+//
+//	{"columns":[...],"rows":[{...}],"rows_affected":0,"message":"","elapsed_ms":0.1}
+//
+// Each row is an object keyed by column name, in column order.
+type JSON struct{}
+
+func (JSON) Format(w io.Writer, rs *executor.ResultSet) error {
+	var buf bytes.Buffer
+	buf.WriteString(`{"columns":`)
+	columns := rs.Columns
+	if columns == nil {
+		columns = []executor.Column{}
+	}
+	if err := writeJSON(&buf, columns); err != nil {
+		return err
+	}
+
+	buf.WriteString(`,"rows":[`)
+	for i, row := range rs.Rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeRowObject(&buf, rs.Columns, row); err != nil {
+			return err
+		}
+	}
+	buf.WriteString(`],"rows_affected":`)
+	fmt.Fprintf(&buf, "%d", rs.RowsAffected)
+	buf.WriteString(`,"message":`)
+	if err := writeJSON(&buf, rs.Message); err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, `,"elapsed_ms":%.3f}`, float64(rs.Elapsed.Microseconds())/1000)
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// NDJSON renders one JSON object per row and line, which suits streaming
+// into other tools. A result without rows prints a single status object.
+type NDJSON struct{}
+
+func (NDJSON) Format(w io.Writer, rs *executor.ResultSet) error {
+	columns, rows := tabular(rs)
+	var buf bytes.Buffer
+	for _, row := range rows {
+		if err := writeRowObject(&buf, columns, row); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeRowObject writes a row as a JSON object, keeping column order.
+func writeRowObject(buf *bytes.Buffer, columns []executor.Column, row []interface{}) error {
+	buf.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSON(buf, col.Name); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		var v interface{}
+		if i < len(row) {
+			v = row[i]
+		}
+		if err := writeJSON(buf, v); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
@@ -0,0 +1,80 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"dagenie/internal/dql/executor"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Table renders rows as a coloured ASCII table followed by any status
+// message. This is the interactive default.
+type Table struct{}
+
+func (Table) Format(w io.Writer, rs *executor.ResultSet) error {
+	if len(rs.Columns) > 0 {
+		if len(rs.Rows) == 0 {
+			fmt.Fprintln(w, "❌ No results")
+		} else {
+			renderTable(w, rs)
+		}
+	}
+	if rs.Message != "" {
+		fmt.Fprintln(w, rs.Message)
+	}
+	if len(rs.Columns) > 0 {
+		fmt.Fprintf(w, "\033[32m✅ Done (%d row(s) in %s)\033[0m\n", len(rs.Rows), rs.Elapsed.Round(time.Microsecond))
+	}
+	return nil
+}
+
+func renderTable(w io.Writer, rs *executor.ResultSet) {
+	// Prepare headers
+	headers := make([]string, 0, len(rs.Columns))
+	for _, col := range rs.Columns {
+		header := strings.ToUpper(col.Name)
+		if header == "_ID" {
+			header = "ObjectID"
+		}
+		headers = append(headers, header)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(headers)
+
+	// Color settings: white headers, green fields
+	headerColors := make([]tablewriter.Colors, len(headers))
+	colColors := make([]tablewriter.Colors, len(headers))
+	for i := range headers {
+		headerColors[i] = tablewriter.Colors{tablewriter.FgHiWhiteColor}
+		colColors[i] = tablewriter.Colors{tablewriter.FgGreenColor}
+	}
+	table.SetHeaderColor(headerColors...)
+	table.SetColumnColor(colColors...)
+	table.SetBorder(true)
+
+	for _, row := range rs.Rows {
+		cells := make([]string, 0, len(row))
+		for i, v := range row {
+			cells = append(cells, tableCell(v, rs.Columns[i].Type))
+		}
+		table.Append(cells)
+	}
+	table.Render()
+}
+
+// tableCell renders a value for display: NULL for missing values and two
+// decimals for floats.
+func tableCell(v interface{}, typ executor.ColumnType) string {
+	if v == nil {
+		return "NULL"
+	}
+	if f, ok := v.(float64); ok && typ == executor.TypeFloat {
+		return fmt.Sprintf("%.2f", f)
+	}
+	return Text(v, typ)
+}
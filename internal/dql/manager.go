@@ -0,0 +1,236 @@
+package dql
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"dagenie/internal/dql/storage"
+)
+
+// DefaultIdleTimeout is how long an unused database stays open before the
+// manager closes it.
+const DefaultIdleTimeout = 5 * time.Minute
+
+// Manager owns the named databases under a data root and is safe for
+// concurrent use. The databases that exist are the ones in the root's
+// catalog. Databases are opened on first Acquire, shared between sessions,
+// reference counted, and closed once they have been unused for the idle
+// timeout. A database is only dropped while nobody uses it.
+type Manager struct {
+	mu          sync.Mutex
+	root        string
+	idleTimeout time.Duration
+	catalog     *catalog
+	dbs         map[string]*managedDB
+}
+
+type managedDB struct {
+	db        *storage.Database
+	refs      int
+	idleTimer *time.Timer
+}
+
+// NewManager returns a manager for the databases under root, creating the
+// directory and its catalog if needed. The root is resolved to an absolute
+// path so that it does not depend on the working directory later on. An
+// idleTimeout of 0 closes databases as soon as their last user releases
+// them.
+func NewManager(root string, idleTimeout time.Duration) (*Manager, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("❌ Invalid data directory '%s': %v", root, err)
+	}
+	if err := os.MkdirAll(absRoot, 0755); err != nil {
+		return nil, fmt.Errorf("❌ Failed to create data directory '%s': %v", absRoot, err)
+	}
+	c, err := loadCatalog(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		root:        absRoot,
+		idleTimeout: idleTimeout,
+		catalog:     c,
+		dbs:         make(map[string]*managedDB),
+	}, nil
+}
+
+// Root returns the absolute data directory of the manager.
+func (m *Manager) Root() string {
+	return m.root
+}
+
+// validDBName rejects names that would escape the root directory.
+func validDBName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("❌ Invalid database name")
+	}
+	return nil
+}
+
+func (m *Manager) path(name string) string {
+	return filepath.Join(m.root, name)
+}
+
+// Create makes the directory of a new database and records it in the
+// catalog.
+func (m *Manager) Create(name, owner string, options map[string]string) error {
+	if err := validDBName(name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dbPath := m.path(name)
+	if _, ok := m.catalog.databases[name]; ok {
+		return fmt.Errorf("❌ Database exists")
+	}
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("❌ Database exists")
+	}
+	if err := os.MkdirAll(dbPath, 0755); err != nil {
+		return fmt.Errorf("❌ Create DB failed: %v", err)
+	}
+
+	m.catalog.databases[name] = &DatabaseInfo{
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+		Owner:     owner,
+		Options:   options,
+	}
+	if err := m.catalog.save(); err != nil {
+		delete(m.catalog.databases, name)
+		os.RemoveAll(dbPath)
+		return err
+	}
+	return nil
+}
+
+// Acquire returns the named database, opening it if needed, and counts the
+// caller as a user until it calls Release.
+func (m *Manager) Acquire(name string) (*storage.Database, error) {
+	if err := validDBName(name); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry, ok := m.dbs[name]; ok {
+		if entry.idleTimer != nil {
+			entry.idleTimer.Stop()
+			entry.idleTimer = nil
+		}
+		entry.refs++
+		return entry.db, nil
+	}
+
+	if _, ok := m.catalog.databases[name]; !ok {
+		return nil, fmt.Errorf("❌ Database '%s' does not exist", name)
+	}
+	db, err := storage.OpenDatabase(m.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("❌ Failed to open DB '%s': %v", name, err)
+	}
+	m.dbs[name] = &managedDB{db: db, refs: 1}
+	return db, nil
+}
+
+// Release ends one use of a database obtained from Acquire. The last
+// release schedules the database to be closed after the idle timeout.
+func (m *Manager) Release(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.dbs[name]
+	if !ok || entry.refs == 0 {
+		return
+	}
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	if m.idleTimeout <= 0 {
+		m.closeLocked(name, entry)
+		return
+	}
+	entry.idleTimer = time.AfterFunc(m.idleTimeout, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Only close if nobody picked the database up again meanwhile.
+		if current, ok := m.dbs[name]; ok && current == entry && entry.refs == 0 {
+			m.closeLocked(name, entry)
+		}
+	})
+}
+
+func (m *Manager) closeLocked(name string, entry *managedDB) {
+	if entry.idleTimer != nil {
+		entry.idleTimer.Stop()
+	}
+	if err := entry.db.Close(); err != nil {
+		fmt.Printf("❌ Failed to close DB '%s': %v\n", name, err)
+	}
+	delete(m.dbs, name)
+}
+
+// Drop deletes a database. It is rejected while any session uses the
+// database; an idle open database is closed first.
+func (m *Manager) Drop(name string) error {
+	if err := validDBName(name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.catalog.databases[name]; !ok {
+		return fmt.Errorf("❌ Database '%s' not found", name)
+	}
+	if entry, ok := m.dbs[name]; ok {
+		if entry.refs > 0 {
+			return fmt.Errorf("❌ Database '%s' is in use by %d session(s)", name, entry.refs)
+		}
+		m.closeLocked(name, entry)
+	}
+	if err := os.RemoveAll(m.path(name)); err != nil {
+		return fmt.Errorf("❌ Delete failed: %v", err)
+	}
+	delete(m.catalog.databases, name)
+	return m.catalog.save()
+}
+
+// Exists reports whether the catalog has a database of the given name.
+func (m *Manager) Exists(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.catalog.databases[name]
+	return ok
+}
+
+// List returns copies of the catalog entries of all databases, sorted by
+// name, with their current sizes. The catalog itself is left as it is.
+func (m *Manager) List() ([]DatabaseInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []DatabaseInfo
+	for _, info := range m.catalog.list() {
+		entry := *info
+		entry.SizeBytes = dirSize(m.path(entry.Name))
+		infos = append(infos, entry)
+	}
+	return infos, nil
+}
+
+// Close closes every open database, whether in use or not. It is meant
+// for server shutdown.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, entry := range m.dbs {
+		m.closeLocked(name, entry)
+	}
+}
@@ -1,48 +1,41 @@
 package parser
 
 import (
-	"fmt"
-	"strings"
+	"dagenie/internal/dql/ast"
 )
 
-// parseFromWhere extracts the table name and WHERE conditions from the FROM clause.
-func parseFromWhere(fromRest string) (string, map[string]string, error) {
-	fromRestLower := strings.ToLower(fromRest)
-
-	// Find WHERE clause if present
-	whereIdx := strings.Index(fromRestLower, "where")
-
-	tablePart := fromRest
-	wherePart := ""
-
-	if whereIdx != -1 {
-		tablePart = strings.TrimSpace(fromRest[:whereIdx])
-		wherePart = strings.TrimSpace(fromRest[whereIdx+5:])
+// parseFromWhere parses the table name and optional WHERE clause that
+// follow FROM.
+func (p *dqlParser) parseFromWhere() (string, ast.LogicalNode, error) {
+	tableName, err := p.expectIdent("table name")
+	if err != nil {
+		return "", nil, err
 	}
-
-	// Extract clean table name (no trailing group/order/limit)
-	tableTokens := strings.Fields(tablePart)
-	if len(tableTokens) == 0 {
-		return "", nil, fmt.Errorf("❌ Missing table name")
+	where, err := p.parseOptionalWhere()
+	if err != nil {
+		return "", nil, err
 	}
-	tableName := tableTokens[0]
+	return tableName, where, nil
+}
 
-	// Parse WHERE conditions (basic field='value' AND ...)
-	conditions := make(map[string]string)
-	if wherePart != "" {
-		clauses := strings.Split(wherePart, "and")
-		for _, clause := range clauses {
-			parts := strings.SplitN(clause, "=", 2)
-			if len(parts) != 2 {
-				return "", nil, fmt.Errorf("❌ Invalid WHERE clause: %s", clause)
-			}
-			field := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			// Remove surrounding quotes
-			value = strings.Trim(value, "'\"")
-			conditions[strings.ToLower(field)] = value
+// parseOptionalReturning parses RETURNING * or a comma-separated list of
+// fields after a write statement. It returns nil when there is none.
+func (p *dqlParser) parseOptionalReturning() ([]string, error) {
+	if !p.acceptWord("RETURNING") {
+		return nil, nil
+	}
+	if p.acceptPunct("*") {
+		return []string{"*"}, nil
+	}
+	var fields []string
+	for {
+		field, err := p.parseFieldRef("field name in RETURNING")
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+		if !p.acceptPunct(",") {
+			return fields, nil
 		}
 	}
-
-	return strings.ToLower(tableName), conditions, nil
 }
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+)
+
+// ParseCriticalPathToAST parses
+//
+//	CRITICAL PATH OF DAG 'dagid' [IN TABLE t]
+func ParseCriticalPathToAST(query string) (*ast.CriticalPathQueryAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("CRITICAL") {
+		return nil, fmt.Errorf("❌ Not a CRITICAL PATH query")
+	}
+	for _, word := range []string{"PATH", "OF", "DAG"} {
+		if err := p.expectWord(word); err != nil {
+			return nil, err
+		}
+	}
+
+	dagID, err := p.parseConditionValue()
+	if err != nil {
+		return nil, err
+	}
+	table := "dag"
+	if err := p.parseInClauses(nil, &table); err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	return &ast.CriticalPathQueryAST{DAGID: dagID, Table: table}, nil
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+	"strings"
+)
+
+// ParseDAGToAST parses
+//
+//	COPY DAG 'a' TO 'b' [IN TABLE t]
+//	RENAME DAG 'a' TO 'b' [IN TABLE t]
+//	DROP DAG 'a' [IN TABLE t]
+//	SHOW DAGS [IN TABLE t]
+func ParseDAGToAST(query string) (*ast.DAGQueryAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+
+	dagAST := &ast.DAGQueryAST{Table: "dag"}
+	for _, action := range []string{"COPY", "RENAME", "DROP", "SHOW"} {
+		if p.acceptWord(action) {
+			dagAST.Action = action
+			break
+		}
+	}
+	if dagAST.Action == "" {
+		return nil, fmt.Errorf("❌ Not a COPY/RENAME/DROP DAG or SHOW DAGS query")
+	}
+
+	if dagAST.Action == "SHOW" {
+		if err := p.expectWord("DAGS"); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := p.expectWord("DAG"); err != nil {
+			return nil, err
+		}
+		if dagAST.DAGID, err = p.parseConditionValue(); err != nil {
+			return nil, err
+		}
+		if dagAST.Action != "DROP" {
+			if err := p.expectWord("TO"); err != nil {
+				return nil, err
+			}
+			targetTok := p.peek()
+			if dagAST.Target, err = p.parseConditionValue(); err != nil {
+				return nil, err
+			}
+			if dagAST.Target == "" || strings.Contains(dagAST.Target, " ") {
+				return nil, p.errorf(targetTok, "Invalid DAG ID '%s': cannot be empty or contain spaces", dagAST.Target)
+			}
+		}
+	}
+
+	if err := p.parseInClauses(nil, &dagAST.Table); err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return dagAST, nil
+}
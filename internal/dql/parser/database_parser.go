@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+	"strings"
+)
+
+// ParseCreateDatabaseToAST parses
+//
+//	CREATE DATABASE name [OWNER 'owner'] [WITH (key = value, ...)]
+//
+// Option keys are lower-cased; values keep their text.
+func ParseCreateDatabaseToAST(query string) (*ast.CreateDatabaseAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("CREATE") {
+		return nil, fmt.Errorf("❌ Not a CREATE DATABASE query")
+	}
+	if err := p.expectWord("DATABASE"); err != nil {
+		return nil, err
+	}
+
+	nameTok := p.peek()
+	name, err := p.parseConditionValue()
+	if err != nil || name == "" {
+		return nil, p.errorf(nameTok, "Expected database name, got %s", nameTok)
+	}
+	result := &ast.CreateDatabaseAST{Name: name, Options: map[string]string{}}
+
+	if p.acceptWord("OWNER") {
+		if result.Owner, err = p.parseConditionValue(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptWord("WITH") {
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		for {
+			key, err := p.expectIdent("option name")
+			if err != nil {
+				return nil, err
+			}
+			if tok := p.next(); tok.Kind != TokenOperator || tok.Value != "=" {
+				return nil, p.errorf(tok, "Expected '=', got %s", tok)
+			}
+			value, err := p.parseConditionValue()
+			if err != nil {
+				return nil, err
+			}
+			result.Options[strings.ToLower(key)] = value
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+)
+
+// ParseDependencyToAST parses
+//
+//	ADD DEPENDENCY 'a' TO 'b' [IN DAG 'dagid'] [IN TABLE t]
+//	REMOVE DEPENDENCY 'a' FROM 'b' [IN DAG 'dagid'] [IN TABLE t]
+func ParseDependencyToAST(query string) (*ast.DependencyQueryAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+
+	depAST := &ast.DependencyQueryAST{Table: "dag"}
+	switch {
+	case p.acceptWord("ADD"):
+		depAST.Action = "ADD"
+	case p.acceptWord("REMOVE"):
+		depAST.Action = "REMOVE"
+	default:
+		return nil, fmt.Errorf("❌ Not an ADD/REMOVE DEPENDENCY query")
+	}
+
+	if err := p.expectWord("DEPENDENCY"); err != nil {
+		return nil, err
+	}
+	if depAST.Dependency, err = p.parseConditionValue(); err != nil {
+		return nil, err
+	}
+
+	if depAST.Action == "ADD" {
+		err = p.expectWord("TO")
+	} else {
+		err = p.expectKeyword("FROM")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if depAST.TaskID, err = p.parseConditionValue(); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseInClauses(&depAST.DAGID, &depAST.Table); err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return depAST, nil
+}
@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+)
+
+// ParseDiffToAST parses
+//
+//	DIFF DAG 'a' [IN TABLE t] WITH 'b' [IN TABLE u]
+//
+// The second table defaults to the first, so two versions of a DAG can be
+// compared across tables and two DAGs within one.
+func ParseDiffToAST(query string) (*ast.DiffQueryAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("DIFF") {
+		return nil, fmt.Errorf("❌ Not a DIFF DAG query")
+	}
+	if err := p.expectWord("DAG"); err != nil {
+		return nil, err
+	}
+
+	diffAST := &ast.DiffQueryAST{Table: "dag"}
+	if diffAST.DAGID, err = p.parseConditionValue(); err != nil {
+		return nil, err
+	}
+	if err := p.parseInClauses(nil, &diffAST.Table); err != nil {
+		return nil, err
+	}
+
+	if err := p.expectWord("WITH"); err != nil {
+		return nil, err
+	}
+	diffAST.OtherTable = diffAST.Table
+	if diffAST.OtherDAGID, err = p.parseConditionValue(); err != nil {
+		return nil, err
+	}
+	if err := p.parseInClauses(nil, &diffAST.OtherTable); err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return diffAST, nil
+}
@@ -0,0 +1,287 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dqlParser is a recursive-descent parser over the token stream produced by
+// Lex. Every statement parser in this package is built on it.
+type dqlParser struct {
+	tokens []Token
+	pos    int
+}
+
+func newParser(query string) (*dqlParser, error) {
+	tokens, err := Lex(query)
+	if err != nil {
+		return nil, err
+	}
+	return &dqlParser{tokens: tokens}, nil
+}
+
+func (p *dqlParser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *dqlParser) peekAt(offset int) Token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *dqlParser) next() Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != TokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *dqlParser) errorf(tok Token, format string, args ...interface{}) error {
+	return &ParseError{Line: tok.Line, Col: tok.Col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// isKeyword reports whether the current token is the given keyword.
+func (p *dqlParser) isKeyword(kw string) bool {
+	tok := p.peek()
+	return tok.Kind == TokenKeyword && tok.Value == kw
+}
+
+// isWord reports whether the current token is the given keyword or a
+// non-reserved word spelled the same way (case-insensitive).
+func (p *dqlParser) isWord(word string) bool {
+	tok := p.peek()
+	return (tok.Kind == TokenKeyword || tok.Kind == TokenIdent) && strings.EqualFold(tok.Value, word)
+}
+
+func (p *dqlParser) isPunct(punct string) bool {
+	tok := p.peek()
+	return tok.Kind == TokenPunct && tok.Value == punct
+}
+
+func (p *dqlParser) acceptKeyword(kw string) bool {
+	if p.isKeyword(kw) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *dqlParser) acceptWord(word string) bool {
+	if p.isWord(word) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *dqlParser) acceptPunct(punct string) bool {
+	if p.isPunct(punct) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *dqlParser) expectKeyword(kw string) error {
+	if !p.acceptKeyword(kw) {
+		return p.errorf(p.peek(), "Expected %s, got %s", kw, p.peek())
+	}
+	return nil
+}
+
+func (p *dqlParser) expectWord(word string) error {
+	if !p.acceptWord(word) {
+		return p.errorf(p.peek(), "Expected %s, got %s", strings.ToUpper(word), p.peek())
+	}
+	return nil
+}
+
+func (p *dqlParser) expectPunct(punct string) error {
+	if !p.acceptPunct(punct) {
+		return p.errorf(p.peek(), "Expected '%s', got %s", punct, p.peek())
+	}
+	return nil
+}
+
+// expectIdent consumes an identifier and returns it lower-cased, which is
+// how table and field names are stored in the AST.
+func (p *dqlParser) expectIdent(what string) (string, error) {
+	tok := p.peek()
+	if tok.Kind != TokenIdent {
+		return "", p.errorf(tok, "Expected %s, got %s", what, tok)
+	}
+	p.next()
+	return strings.ToLower(tok.Value), nil
+}
+
+// parseFieldRef parses a field name, optionally followed by a JSON path
+// operator: field->'$.path' for the JSON value at the path, or
+// field->>'$.path' for its text. Either is returned in the spelling of
+// ast.PathField.
+func (p *dqlParser) parseFieldRef(what string) (string, error) {
+	field, err := p.expectIdent(what)
+	if err != nil {
+		return "", err
+	}
+	arrow := p.peek()
+	if arrow.Kind != TokenOperator || (arrow.Value != "->" && arrow.Value != "->>") {
+		return field, nil
+	}
+	p.next()
+	path, err := p.parseJSONPath(arrow.Value)
+	if err != nil {
+		return "", err
+	}
+	return ast.PathField{Field: field, Arrow: arrow.Value, Path: path}.String(), nil
+}
+
+// parseJSONPath consumes a quoted JSON path such as '$.retries.backoff'.
+func (p *dqlParser) parseJSONPath(after string) (ast.JSONPath, error) {
+	tok := p.peek()
+	if tok.Kind != TokenString {
+		return ast.JSONPath{}, p.errorf(tok, "Expected JSON path after %s, got %s", after, tok)
+	}
+	p.next()
+	path, err := ast.ParseJSONPath(tok.Value)
+	if err != nil {
+		return ast.JSONPath{}, p.errorf(tok, "%v", err)
+	}
+	return path, nil
+}
+
+// expectEnd accepts an optional trailing semicolon and requires the end of
+// the statement.
+func (p *dqlParser) expectEnd() error {
+	p.acceptPunct(";")
+	if tok := p.peek(); tok.Kind != TokenEOF {
+		return p.errorf(tok, "Unexpected %s", tok)
+	}
+	return nil
+}
+
+// parseLiteral consumes a value literal (string, number, TRUE, FALSE or
+// JSON) and returns its text. A leading '-' is folded into numeric
+// literals. NULL is rejected; see parseValue.
+func (p *dqlParser) parseLiteral() (string, error) {
+	tok := p.peek()
+	lit, err := p.parseValue()
+	if err != nil {
+		return "", err
+	}
+	if lit.IsNull() {
+		return "", p.errorf(tok, "NULL is not allowed here, use IS NULL or IS NOT NULL")
+	}
+	return lit.Text, nil
+}
+
+// parseValue consumes a literal, including NULL, together with how it was
+// written. Bare words other than TRUE and FALSE are read as strings for
+// backwards compatibility with unquoted values such as status = done; see
+// parseBareValue.
+func (p *dqlParser) parseValue() (ast.Literal, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokenString:
+		p.next()
+		return ast.Literal{Kind: ast.LiteralString, Text: tok.Value}, nil
+	case TokenNumber:
+		if text, joined := p.parseBareValue(); joined {
+			return ast.Literal{Kind: ast.LiteralString, Text: text}, nil
+		}
+		return ast.Literal{Kind: ast.LiteralNumber, Text: tok.Value}, nil
+	case TokenJSON:
+		p.next()
+		return ast.Literal{Kind: ast.LiteralJSON, Text: tok.Value}, nil
+	case TokenKeyword:
+		if tok.Value == "NULL" {
+			p.next()
+			return ast.Literal{Kind: ast.LiteralNull}, nil
+		}
+	case TokenIdent:
+		text, joined := p.parseBareValue()
+		if !joined && (strings.EqualFold(text, "true") || strings.EqualFold(text, "false")) {
+			return ast.Literal{Kind: ast.LiteralBool, Text: strings.ToLower(text)}, nil
+		}
+		return ast.Literal{Kind: ast.LiteralString, Text: text}, nil
+	case TokenOperator:
+		if tok.Value == "-" && p.peekAt(1).Kind == TokenNumber {
+			p.next()
+			return ast.Literal{Kind: ast.LiteralNumber, Text: "-" + p.next().Value}, nil
+		}
+	}
+	return ast.Literal{}, p.errorf(tok, "Expected value, got %s", tok)
+}
+
+// parseBareValue consumes an unquoted word or number. Words and numbers
+// joined by '-' with no space around it, as in abc-1 or 2024-01-31, make
+// up a single value; joined reports whether there was more than one part.
+func (p *dqlParser) parseBareValue() (text string, joined bool) {
+	last := p.next()
+	text = last.Value
+	for {
+		dash, part := p.peek(), p.peekAt(1)
+		if dash.Kind != TokenOperator || dash.Value != "-" || !adjacent(last, dash) ||
+			(part.Kind != TokenIdent && part.Kind != TokenNumber) || !adjacent(dash, part) {
+			return text, joined
+		}
+		p.next()
+		last = p.next()
+		text += "-" + last.Value
+		joined = true
+	}
+}
+
+// adjacent reports whether b starts right where a ends. It only holds for
+// tokens whose value is their source text.
+func adjacent(a, b Token) bool {
+	return a.Line == b.Line && a.Col+len([]rune(a.Value)) == b.Col
+}
+
+// parseInClauses parses the IN DAG 'dagid' and IN TABLE name qualifiers
+// that may follow graph and dependency statements, in either order. A nil
+// dagID means IN DAG is not allowed. table is left untouched when absent.
+func (p *dqlParser) parseInClauses(dagID, table *string) error {
+	for p.isKeyword("IN") {
+		next := p.peekAt(1)
+		switch {
+		case dagID != nil && strings.EqualFold(next.Value, "DAG"):
+			p.next()
+			p.next()
+			value, err := p.parseConditionValue()
+			if err != nil {
+				return err
+			}
+			*dagID = value
+		case strings.EqualFold(next.Value, "TABLE"):
+			p.next()
+			p.next()
+			name, err := p.expectIdent("table name")
+			if err != nil {
+				return err
+			}
+			*table = name
+		default:
+			return p.errorf(next, "Expected DAG or TABLE after IN, got %s", next)
+		}
+	}
+	return nil
+}
+
+// parseInt consumes an integer literal.
+func (p *dqlParser) parseInt(what string) (int, error) {
+	tok := p.peek()
+	if tok.Kind != TokenNumber || strings.ContainsAny(tok.Value, ".eE") {
+		return 0, p.errorf(tok, "Expected integer %s, got %s", what, tok)
+	}
+	n, err := strconv.Atoi(tok.Value)
+	if err != nil {
+		return 0, p.errorf(tok, "Integer %s %s is out of range", what, tok.Value)
+	}
+	p.next()
+	return n, nil
+}
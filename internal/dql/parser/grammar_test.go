@@ -0,0 +1,150 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// formatNode spells a WHERE tree with explicit parentheses so tests can
+// check how it was grouped.
+func formatNode(node ast.LogicalNode) string {
+	switch n := node.(type) {
+	case *ast.AndNode:
+		return fmt.Sprintf("(%s AND %s)", formatNode(n.Left), formatNode(n.Right))
+	case *ast.OrNode:
+		return fmt.Sprintf("(%s OR %s)", formatNode(n.Left), formatNode(n.Right))
+	case *ast.NotNode:
+		return fmt.Sprintf("NOT %s", formatNode(n.Expr))
+	case *ast.ConditionNode:
+		if n.Values != nil {
+			return fmt.Sprintf("%s %s [%s]", n.Field, n.Operator, strings.Join(n.Values, "|"))
+		}
+		if n.Value == "" {
+			return fmt.Sprintf("%s %s", n.Field, n.Operator)
+		}
+		return fmt.Sprintf("%s %s %s", n.Field, n.Operator, n.Value)
+	case nil:
+		return "<nil>"
+	default:
+		return fmt.Sprintf("%T", node)
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{where: "a = 1 OR b = 2 AND c = 3", want: "(a = 1 OR (b = 2 AND c = 3))"},
+		{where: "(a = 1 OR b = 2) AND c = 3", want: "((a = 1 OR b = 2) AND c = 3)"},
+		{where: "NOT a = 1 AND b = 2", want: "(NOT a = 1 AND b = 2)"},
+		{where: "NOT (a = 1 OR b = 2)", want: "NOT (a = 1 OR b = 2)"},
+		{where: "a = 1 AND b = 2 AND c = 3", want: "((a = 1 AND b = 2) AND c = 3)"},
+		{where: "duration BETWEEN 1 AND 5 AND status = done", want: "(duration BETWEEN [1|5] AND status = done)"},
+		{where: "duration NOT BETWEEN -2 AND 3.5", want: "duration NOT BETWEEN [-2|3.5]"},
+		{where: "id IN ('a', 'b''c', 3)", want: "id IN [a|b'c|3]"},
+		{where: "name = 'it''s'", want: "name = it's"},
+		{where: `payload = {"k": [1, 2]}`, want: `payload = {"k": [1, 2]}`},
+		{where: "name NOT ILIKE 'x%'", want: "name NOT ILIKE x%"},
+		{where: "status IS NOT NULL", want: "status IS NOT NULL"},
+		{where: "done = TRUE", want: "done = true"},
+		{where: "dagid = abc-1", want: "dagid = abc-1"},
+		{where: "id = 2024-01-31", want: "id = 2024-01-31"},
+		{where: "payload->>'$.owner' = bob", want: "payload->>'$.owner' = bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			table, where, err := ParseWhereClause("dag WHERE " + tt.where)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if table != "dag" {
+				t.Errorf("table = %q, want dag", table)
+			}
+			if got := formatNode(where); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	selectAST, err := ParseSelectToAST("SELECT * FROM dag LIMIT 25")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if selectAST.Limit != 25 {
+		t.Errorf("Limit = %d, want 25", selectAST.Limit)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query     string
+		line, col int
+		msg       string
+	}{
+		{query: "SELECT * FROM dag LIMIT 99999999999999999999", line: 1, col: 25, msg: "out of range"},
+		{query: "SELECT * FROM dag LIMIT 2.5", line: 1, col: 25, msg: "Expected integer LIMIT"},
+		{query: "SELECT * FROM dag WHERE duration BETWEEN 1 OR 5", line: 1, col: 44, msg: "Expected AND"},
+		{query: "SELECT * FROM dag WHERE (a = 1", line: 1, col: 31, msg: "Expected ')'"},
+		{query: "SELECT * FROM dag\nWHERE a = 1 b", line: 2, col: 13, msg: "Unexpected b"},
+		{query: "SELECT * FROM dag WHERE a NOT = 1", line: 1, col: 31, msg: "after NOT"},
+		{query: "SELECT * FROM dag WHERE a = NULL", line: 1, col: 29, msg: "IS NULL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseSelectToAST(tt.query)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("error = %v, want a ParseError", err)
+			}
+			if perr.Line != tt.line || perr.Col != tt.col {
+				t.Errorf("error at %d:%d, want %d:%d (%v)", perr.Line, perr.Col, tt.line, tt.col, err)
+			}
+			if !strings.Contains(perr.Msg, tt.msg) {
+				t.Errorf("error %q does not mention %q", perr.Msg, tt.msg)
+			}
+		})
+	}
+}
+
+func TestParseSetList(t *testing.T) {
+	updateAST, err := ParseUpdateToAST("UPDATE dag SET status = 'b', name = n, duration = 3 WHERE id = '1'")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	var got []string
+	for _, set := range updateAST.SetFields {
+		got = append(got, set.Field+"="+set.Value.Text)
+	}
+	if want := "status=b name=n duration=3"; strings.Join(got, " ") != want {
+		t.Errorf("SET list = %v, want %s", got, want)
+	}
+
+	duplicates := []struct {
+		query string
+		parse func(string) error
+	}{
+		{
+			query: "UPDATE dag SET status = 'a', status = 'b'",
+			parse: func(q string) error { _, err := ParseUpdateToAST(q); return err },
+		},
+		{
+			query: "INSERT INTO dag (id, dagid) VALUES ('1', 'd') ON CONFLICT DO UPDATE SET status = 'a', STATUS = EXCLUDED.status",
+			parse: func(q string) error { _, err := ParseInsertToAST(q); return err },
+		},
+	}
+	for _, tt := range duplicates {
+		query, err := tt.query, tt.parse(tt.query)
+		var perr *ParseError
+		if !errors.As(err, &perr) || !strings.Contains(perr.Msg, "Field status is set twice") {
+			t.Errorf("%s: error = %v, want a ParseError for status set twice", query, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+	"strings"
+)
+
+// parseGraphSource parses a dependency-graph traversal used in place of a
+// table name:
+//
+//	DESCENDANTS('id') | ANCESTORS('id') [IN DAG 'dagid'] [IN TABLE t] [MAX DEPTH n]
+//	PATH FROM 'a' TO 'b' [IN DAG 'dagid'] [IN TABLE t]
+//
+// ok is false, and nothing is consumed, when FROM names a plain table.
+func (p *dqlParser) parseGraphSource() (*ast.GraphSource, bool, error) {
+	tok := p.peek()
+	next := p.peekAt(1)
+	kind := strings.ToUpper(tok.Value)
+
+	src := &ast.GraphSource{Kind: kind, Table: "dag"}
+	switch {
+	case tok.Kind == TokenIdent && (kind == "DESCENDANTS" || kind == "ANCESTORS") &&
+		next.Kind == TokenPunct && next.Value == "(":
+		p.next()
+		p.next()
+		id, err := p.parseConditionValue()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, false, err
+		}
+		src.TaskID = id
+
+	case tok.Kind == TokenIdent && kind == "PATH" && next.Kind == TokenKeyword && next.Value == "FROM":
+		p.next()
+		p.next()
+		from, err := p.parseConditionValue()
+		if err != nil {
+			return nil, false, err
+		}
+		if err := p.expectWord("TO"); err != nil {
+			return nil, false, err
+		}
+		to, err := p.parseConditionValue()
+		if err != nil {
+			return nil, false, err
+		}
+		src.TaskID, src.TargetID = from, to
+
+	default:
+		return nil, false, nil
+	}
+
+	if err := p.parseInClauses(&src.DAGID, &src.Table); err != nil {
+		return nil, false, err
+	}
+
+	if src.Kind != "PATH" && p.isWord("MAX") {
+		p.next()
+		if err := p.expectWord("DEPTH"); err != nil {
+			return nil, false, err
+		}
+		depthTok := p.peek()
+		depth, err := p.parseInt("MAX DEPTH")
+		if err != nil {
+			return nil, false, err
+		}
+		if depth < 1 {
+			return nil, false, p.errorf(depthTok, "MAX DEPTH must be at least 1")
+		}
+		src.MaxDepth = depth
+	}
+
+	return src, true, nil
+}
+
+// ParsePathToAST parses the standalone form
+//
+//	PATH FROM 'a' TO 'b' [IN DAG 'dagid'] [IN TABLE t] [WHERE ...]
+//
+// as SELECT * FROM PATH FROM 'a' TO 'b' ...
+func ParsePathToAST(query string) (*ast.SelectQueryAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	src, ok, err := p.parseGraphSource()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("❌ Not a PATH query")
+	}
+
+	where, err := p.parseOptionalWhere()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+
+	return &ast.SelectQueryAST{
+		Fields: []string{"*"},
+		Table:  src.Table,
+		Graph:  src,
+		Where:  where,
+	}, nil
+}
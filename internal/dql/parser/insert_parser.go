@@ -4,50 +4,146 @@ package parser
 import (
 	"dagenie/internal/dql/ast"
 	"fmt"
-	"regexp"
-	"strings"
 )
 
-// ParseInsertToAST parses an INSERT query into InsertQueryAST.
+// ParseInsertToAST parses an INSERT query into InsertQueryAST:
+//
+//	INSERT INTO table [(col1, col2, ...)] VALUES (v1, v2, ...)[, (...)]...
+//	    [ON CONFLICT [(dagid, id)] DO NOTHING | DO UPDATE SET field = value | EXCLUDED.field, ...]
+//	    [RETURNING * | field, ...]
 func ParseInsertToAST(query string) (*ast.InsertQueryAST, error) {
-	query = strings.TrimSpace(query)
-	lowerQuery := strings.ToLower(query)
-
-	if !strings.HasPrefix(lowerQuery, "insert into") {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptKeyword("INSERT") {
 		return nil, fmt.Errorf("❌ Not an INSERT query")
 	}
+	if err := p.expectKeyword("INTO"); err != nil {
+		return nil, err
+	}
+	table, err := p.expectIdent("table name")
+	if err != nil {
+		return nil, err
+	}
+	result := &ast.InsertQueryAST{Table: table}
+
+	if p.acceptPunct("(") {
+		for {
+			col, err := p.expectIdent("column name")
+			if err != nil {
+				return nil, err
+			}
+			result.Columns = append(result.Columns, col)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+	}
 
-	// Regex for INSERT INTO dag (col1, col2, ...) VALUES ('val1', 'val2', ...)
-	pattern := `(?i)^insert\s+into\s+(\w+)\s*\(([^)]+)\)\s+values\s*\(([^)]+)\)$`
-	re := regexp.MustCompile(pattern)
-	matches := re.FindStringSubmatch(query)
-	if len(matches) != 4 {
-		return nil, fmt.Errorf("❌ Invalid INSERT syntax. Expected: INSERT INTO dag (col1, ...) VALUES ('val1', ...)")
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		tupleTok := p.peek()
+		row, err := p.parseValueTuple()
+		if err != nil {
+			return nil, err
+		}
+		if result.Columns != nil && len(row) != len(result.Columns) {
+			return nil, p.errorf(tupleTok, "Row %d has %d values for %d columns", len(result.Rows)+1, len(row), len(result.Columns))
+		}
+		if len(result.Rows) > 0 && len(row) != len(result.Rows[0]) {
+			return nil, p.errorf(tupleTok, "Row %d has %d values, expected %d", len(result.Rows)+1, len(row), len(result.Rows[0]))
+		}
+		result.Rows = append(result.Rows, row)
+		if !p.acceptPunct(",") {
+			break
+		}
 	}
 
-	table := strings.ToLower(matches[1])
-	columns := splitCSV(matches[2])
-	values := splitCSV(matches[3])
+	if p.acceptWord("ON") {
+		if result.OnConflict, err = p.parseOnConflict(); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.Returning, err = p.parseOptionalReturning(); err != nil {
+		return nil, err
+	}
 
-	if len(columns) != len(values) {
-		return nil, fmt.Errorf("❌ Column count does not match value count")
+	if err := p.expectEnd(); err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	return &ast.InsertQueryAST{
-		Table:   table,
-		Columns: columns,
-		Values:  values,
-	}, nil
+// parseValueTuple parses one parenthesized VALUES row.
+func (p *dqlParser) parseValueTuple() ([]ast.Literal, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var values []ast.Literal
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return values, nil
 }
 
-// Helper: Split CSV, trim spaces, strip quotes from values
-func splitCSV(input string) []string {
-	parts := strings.Split(input, ",")
-	var result []string
-	for _, p := range parts {
-		trimmed := strings.TrimSpace(p)
-		trimmed = strings.Trim(trimmed, `"'`)
-		result = append(result, trimmed)
+// parseOnConflict parses the part of ON CONFLICT after ON. The only
+// conflict target is the task key, (dagid, id) in either order.
+func (p *dqlParser) parseOnConflict() (*ast.OnConflictAST, error) {
+	if err := p.expectWord("CONFLICT"); err != nil {
+		return nil, err
+	}
+	if p.isPunct("(") {
+		targetTok := p.next()
+		var target []string
+		for {
+			col, err := p.expectIdent("conflict column")
+			if err != nil {
+				return nil, err
+			}
+			target = append(target, col)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		if len(target) != 2 || !(target[0] == "dagid" && target[1] == "id" || target[0] == "id" && target[1] == "dagid") {
+			return nil, p.errorf(targetTok, "ON CONFLICT target must be the task key (dagid, id)")
+		}
+	}
+	if err := p.expectWord("DO"); err != nil {
+		return nil, err
+	}
+	if p.acceptWord("NOTHING") {
+		return &ast.OnConflictAST{DoNothing: true}, nil
+	}
+	if err := p.expectKeyword("UPDATE"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+	setFields, err := p.parseSetList(true)
+	if err != nil {
+		return nil, err
 	}
-	return result
+	return &ast.OnConflictAST{SetFields: setFields}, nil
 }
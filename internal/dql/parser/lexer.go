@@ -0,0 +1,317 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TokenKind classifies a lexical token of a DQL statement.
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenKeyword
+	TokenString
+	TokenNumber
+	TokenJSON
+	TokenOperator
+	TokenPunct
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenEOF:
+		return "end of query"
+	case TokenIdent:
+		return "identifier"
+	case TokenKeyword:
+		return "keyword"
+	case TokenString:
+		return "string"
+	case TokenNumber:
+		return "number"
+	case TokenJSON:
+		return "JSON literal"
+	case TokenOperator:
+		return "operator"
+	case TokenPunct:
+		return "punctuation"
+	default:
+		return "token"
+	}
+}
+
+// Token is a single lexeme together with the position it started at.
+// Keywords are upper-cased, string literals are unquoted and unescaped,
+// everything else keeps its source text.
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Line  int
+	Col   int
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case TokenEOF:
+		return "end of query"
+	case TokenString:
+		return fmt.Sprintf("'%s'", t.Value)
+	default:
+		return t.Value
+	}
+}
+
+// ParseError is returned for any lexical or syntax error and carries the
+// 1-based line and column of the offending token.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("❌ %s at line %d, column %d", e.Msg, e.Line, e.Col)
+}
+
+// keywords are the reserved words of DQL. Any other word is an identifier.
+var keywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true,
+	"AND": true, "OR": true, "NOT": true,
+	"GROUP": true, "ORDER": true, "BY": true, "ASC": true, "DESC": true, "LIMIT": true,
+	"INSERT": true, "INTO": true, "VALUES": true,
+	"UPDATE": true, "SET": true, "DELETE": true,
+	"IN": true, "IS": true, "NULL": true, "BETWEEN": true, "LIKE": true, "ILIKE": true,
+}
+
+type lexer struct {
+	src  []rune
+	pos  int
+	line int
+	col  int
+}
+
+// Lex splits a DQL statement into tokens. The returned slice always ends
+// with a TokenEOF token.
+func Lex(input string) ([]Token, error) {
+	lx := &lexer{src: []rune(input), line: 1, col: 1}
+	var tokens []Token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.Kind == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (lx *lexer) peekRune(offset int) rune {
+	if lx.pos+offset >= len(lx.src) {
+		return 0
+	}
+	return lx.src[lx.pos+offset]
+}
+
+func (lx *lexer) advance() rune {
+	r := lx.src[lx.pos]
+	lx.pos++
+	if r == '\n' {
+		lx.line++
+		lx.col = 1
+	} else {
+		lx.col++
+	}
+	return r
+}
+
+func (lx *lexer) errorf(line, col int, format string, args ...interface{}) error {
+	return &ParseError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (lx *lexer) skipSpaceAndComments() {
+	for lx.pos < len(lx.src) {
+		r := lx.src[lx.pos]
+		switch {
+		case unicode.IsSpace(r):
+			lx.advance()
+		case r == '-' && lx.peekRune(1) == '-':
+			for lx.pos < len(lx.src) && lx.src[lx.pos] != '\n' {
+				lx.advance()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (lx *lexer) next() (Token, error) {
+	lx.skipSpaceAndComments()
+	line, col := lx.line, lx.col
+	if lx.pos >= len(lx.src) {
+		return Token{Kind: TokenEOF, Line: line, Col: col}, nil
+	}
+
+	r := lx.src[lx.pos]
+	switch {
+	case r == '\'' || r == '"':
+		return lx.lexString(r, line, col)
+	case unicode.IsDigit(r):
+		return lx.lexNumber(line, col), nil
+	case isIdentStart(r):
+		return lx.lexWord(line, col), nil
+	case r == '{' || r == '[':
+		return lx.lexJSON(line, col)
+	case r == '(' || r == ')' || r == ',' || r == ';' || r == '*' || r == '.':
+		lx.advance()
+		return Token{Kind: TokenPunct, Value: string(r), Line: line, Col: col}, nil
+	}
+
+	// JSON path operators, then other two-character operators, then
+	// single-character ones.
+	if r == '-' && lx.peekRune(1) == '>' {
+		op := "->"
+		lx.advance()
+		lx.advance()
+		if lx.peekRune(0) == '>' {
+			lx.advance()
+			op = "->>"
+		}
+		return Token{Kind: TokenOperator, Value: op, Line: line, Col: col}, nil
+	}
+	if lx.pos+1 < len(lx.src) {
+		two := string(lx.src[lx.pos : lx.pos+2])
+		switch two {
+		case "<=", ">=", "!=", "<>":
+			lx.advance()
+			lx.advance()
+			if two == "<>" {
+				two = "!="
+			}
+			return Token{Kind: TokenOperator, Value: two, Line: line, Col: col}, nil
+		}
+	}
+	switch r {
+	case '=', '<', '>', '-', '+':
+		lx.advance()
+		return Token{Kind: TokenOperator, Value: string(r), Line: line, Col: col}, nil
+	}
+
+	return Token{}, lx.errorf(line, col, "Unexpected character %q", r)
+}
+
+// lexString reads a quoted literal. A doubled quote inside the literal
+// stands for a single quote character, as in standard SQL.
+func (lx *lexer) lexString(quote rune, line, col int) (Token, error) {
+	lx.advance()
+	var sb strings.Builder
+	for {
+		if lx.pos >= len(lx.src) {
+			return Token{}, lx.errorf(line, col, "Unterminated string literal")
+		}
+		r := lx.advance()
+		if r == quote {
+			if lx.peekRune(0) == quote {
+				lx.advance()
+				sb.WriteRune(quote)
+				continue
+			}
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return Token{Kind: TokenString, Value: sb.String(), Line: line, Col: col}, nil
+}
+
+func (lx *lexer) lexNumber(line, col int) Token {
+	start := lx.pos
+	for lx.pos < len(lx.src) && unicode.IsDigit(lx.src[lx.pos]) {
+		lx.advance()
+	}
+	if lx.peekRune(0) == '.' && unicode.IsDigit(lx.peekRune(1)) {
+		lx.advance()
+		for lx.pos < len(lx.src) && unicode.IsDigit(lx.src[lx.pos]) {
+			lx.advance()
+		}
+	}
+	// Exponent, as in 1e3 or 2.5E-4
+	if e := lx.peekRune(0); e == 'e' || e == 'E' {
+		digits := 1
+		if sign := lx.peekRune(1); sign == '+' || sign == '-' {
+			digits = 2
+		}
+		if unicode.IsDigit(lx.peekRune(digits)) {
+			for i := 0; i < digits; i++ {
+				lx.advance()
+			}
+			for lx.pos < len(lx.src) && unicode.IsDigit(lx.src[lx.pos]) {
+				lx.advance()
+			}
+		}
+	}
+	return Token{Kind: TokenNumber, Value: string(lx.src[start:lx.pos]), Line: line, Col: col}
+}
+
+func (lx *lexer) lexWord(line, col int) Token {
+	start := lx.pos
+	for lx.pos < len(lx.src) && isIdentPart(lx.src[lx.pos]) {
+		lx.advance()
+	}
+	word := string(lx.src[start:lx.pos])
+	if upper := strings.ToUpper(word); keywords[upper] {
+		return Token{Kind: TokenKeyword, Value: upper, Line: line, Col: col}
+	}
+	return Token{Kind: TokenIdent, Value: word, Line: line, Col: col}
+}
+
+// lexJSON reads a bare JSON object or array, balancing brackets and
+// skipping over JSON strings so that commas and parentheses inside the
+// literal never end it early.
+func (lx *lexer) lexJSON(line, col int) (Token, error) {
+	start := lx.pos
+	depth := 0
+	inString := false
+	for lx.pos < len(lx.src) {
+		r := lx.advance()
+		if inString {
+			switch r {
+			case '\\':
+				if lx.pos < len(lx.src) {
+					lx.advance()
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch r {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				text := string(lx.src[start:lx.pos])
+				if !json.Valid([]byte(text)) {
+					return Token{}, lx.errorf(line, col, "Invalid JSON literal %s", text)
+				}
+				return Token{Kind: TokenJSON, Value: text, Line: line, Col: col}, nil
+			}
+		}
+	}
+	return Token{}, lx.errorf(line, col, "Unterminated JSON literal")
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "keywords are upper-cased, identifiers keep their case",
+			input: "select Name from dag",
+			want: []Token{
+				{Kind: TokenKeyword, Value: "SELECT", Line: 1, Col: 1},
+				{Kind: TokenIdent, Value: "Name", Line: 1, Col: 8},
+				{Kind: TokenKeyword, Value: "FROM", Line: 1, Col: 13},
+				{Kind: TokenIdent, Value: "dag", Line: 1, Col: 18},
+				{Kind: TokenEOF, Line: 1, Col: 21},
+			},
+		},
+		{
+			name:  "positions across lines and comments",
+			input: "id = 1 -- first\n  AND x",
+			want: []Token{
+				{Kind: TokenIdent, Value: "id", Line: 1, Col: 1},
+				{Kind: TokenOperator, Value: "=", Line: 1, Col: 4},
+				{Kind: TokenNumber, Value: "1", Line: 1, Col: 6},
+				{Kind: TokenKeyword, Value: "AND", Line: 2, Col: 3},
+				{Kind: TokenIdent, Value: "x", Line: 2, Col: 7},
+				{Kind: TokenEOF, Line: 2, Col: 8},
+			},
+		},
+		{
+			name:  "doubled quotes escape a quote",
+			input: `'it''s' "say ""hi"""`,
+			want: []Token{
+				{Kind: TokenString, Value: "it's", Line: 1, Col: 1},
+				{Kind: TokenString, Value: `say "hi"`, Line: 1, Col: 9},
+				{Kind: TokenEOF, Line: 1, Col: 21},
+			},
+		},
+		{
+			name:  "JSON literals keep their text and ignore brackets in strings",
+			input: `{"a": [1, "}"]}, [2]`,
+			want: []Token{
+				{Kind: TokenJSON, Value: `{"a": [1, "}"]}`, Line: 1, Col: 1},
+				{Kind: TokenPunct, Value: ",", Line: 1, Col: 16},
+				{Kind: TokenJSON, Value: "[2]", Line: 1, Col: 18},
+				{Kind: TokenEOF, Line: 1, Col: 21},
+			},
+		},
+		{
+			name:  "numbers with fraction and exponent",
+			input: "1.5 2e3 7E-2",
+			want: []Token{
+				{Kind: TokenNumber, Value: "1.5", Line: 1, Col: 1},
+				{Kind: TokenNumber, Value: "2e3", Line: 1, Col: 5},
+				{Kind: TokenNumber, Value: "7E-2", Line: 1, Col: 9},
+				{Kind: TokenEOF, Line: 1, Col: 13},
+			},
+		},
+		{
+			name:  "operators",
+			input: "<= >= <> != -> ->> - =",
+			want: []Token{
+				{Kind: TokenOperator, Value: "<=", Line: 1, Col: 1},
+				{Kind: TokenOperator, Value: ">=", Line: 1, Col: 4},
+				{Kind: TokenOperator, Value: "!=", Line: 1, Col: 7},
+				{Kind: TokenOperator, Value: "!=", Line: 1, Col: 10},
+				{Kind: TokenOperator, Value: "->", Line: 1, Col: 13},
+				{Kind: TokenOperator, Value: "->>", Line: 1, Col: 16},
+				{Kind: TokenOperator, Value: "-", Line: 1, Col: 20},
+				{Kind: TokenOperator, Value: "=", Line: 1, Col: 22},
+				{Kind: TokenEOF, Line: 1, Col: 23},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex(%q) failed: %v", tt.input, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Lex(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("token %d of %q = %+v, want %+v", i, tt.input, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLexErrors(t *testing.T) {
+	tests := []struct {
+		input     string
+		line, col int
+	}{
+		{input: "name = 'open", line: 1, col: 8},
+		{input: "x = {\"a\": 1", line: 1, col: 5},
+		{input: "x = {a: 1}", line: 1, col: 5},
+		{input: "x\n  = ?", line: 2, col: 5},
+	}
+
+	for _, tt := range tests {
+		_, err := Lex(tt.input)
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("Lex(%q) error = %v, want a ParseError", tt.input, err)
+			continue
+		}
+		if perr.Line != tt.line || perr.Col != tt.col {
+			t.Errorf("Lex(%q) error at %d:%d, want %d:%d", tt.input, perr.Line, perr.Col, tt.line, tt.col)
+		}
+	}
+}
@@ -3,174 +3,171 @@ package parser
 import (
 	"dagenie/internal/dql/ast"
 	"fmt"
-	"regexp"
 	"strings"
 )
 
-var orderAggRegex = regexp.MustCompile(`(?i)(sum|avg|max|min|count)\s*\(\s*([a-zA-Z0-9_*]+)\s*\)`)
-var aggregateRegex = regexp.MustCompile(`(?i)(sum|avg|max|min|count)\s*\(\s*([a-zA-Z0-9_*]+)\s*\)`)
+// aggregateFuncs are the function names accepted in SELECT and ORDER BY.
+var aggregateFuncs = map[string]bool{"SUM": true, "AVG": true, "MAX": true, "MIN": true, "COUNT": true}
 
+// ParseSelectToAST parses
+//
+//	SELECT <fields> FROM <table> [WHERE ...] [GROUP BY ...] [ORDER BY ...] [LIMIT n]
+//
+// where <table> may also be a graph traversal, see parseGraphSource.
 func ParseSelectToAST(query string) (*ast.SelectQueryAST, error) {
-	query = strings.TrimSpace(query)
-	lowerQuery := strings.ToLower(query)
-
-	if !strings.HasPrefix(lowerQuery, "select") {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptKeyword("SELECT") {
 		return nil, fmt.Errorf("❌ Not a SELECT query")
 	}
 
-	fromIdx := strings.Index(lowerQuery, "from")
-	orderIdx := strings.Index(lowerQuery, "order by")
-	groupIdx := strings.Index(lowerQuery, "group by")
-	limitIdx := strings.Index(lowerQuery, "limit")
-
-	if fromIdx == -1 {
-		return nil, fmt.Errorf("❌ Missing FROM clause")
+	selectAST := &ast.SelectQueryAST{}
+	if err := p.parseSelectList(selectAST); err != nil {
+		return nil, err
 	}
 
-	// Parse LIMIT
-	limit := 0
-	limitStart := len(query)
-	if limitIdx != -1 {
-		limitPart := strings.TrimSpace(query[limitIdx+5:])
-		fmt.Sscanf(limitPart, "%d", &limit)
-		limitStart = limitIdx
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
 	}
-
-	// Parse GROUP BY correctly
-	groupByFields := []string{}
-	groupEnd := limitStart
-	if orderIdx != -1 && orderIdx < limitStart && orderIdx > groupIdx {
-		groupEnd = orderIdx
+	graph, isGraph, err := p.parseGraphSource()
+	if err != nil {
+		return nil, err
 	}
-	if groupIdx != -1 && groupIdx < limitStart {
-		groupPart := strings.TrimSpace(query[groupIdx+8 : groupEnd])
-		parts := strings.Split(groupPart, ",")
-		for _, f := range parts {
-			groupByFields = append(groupByFields, strings.ToLower(strings.TrimSpace(f)))
-		}
-		fmt.Printf("DEBUG Parsed GroupBy fields: %+v\n", groupByFields)
+	if isGraph {
+		selectAST.Table = graph.Table
+		selectAST.Graph = graph
+		selectAST.Where, err = p.parseOptionalWhere()
+	} else {
+		selectAST.Table, selectAST.Where, err = p.parseFromWhere()
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse ORDER BY (unchanged)
-	orderByFields := []ast.OrderByField{}
-	orderEnd := len(query)
-	if orderIdx != -1 && orderIdx < limitStart {
-		if limitIdx != -1 && limitIdx > orderIdx {
-			orderEnd = limitIdx
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
 		}
-		orderPart := strings.TrimSpace(query[orderIdx+8 : orderEnd])
-		clauses := strings.Split(orderPart, ",")
-		for _, clause := range clauses {
-			parts := strings.Fields(strings.TrimSpace(clause))
-			if len(parts) == 0 {
-				continue
+		for {
+			field, err := p.parseFieldRef("GROUP BY field")
+			if err != nil {
+				return nil, err
+			}
+			selectAST.GroupBy = append(selectAST.GroupBy, field)
+			if !p.acceptPunct(",") {
+				break
 			}
-			field := strings.ToLower(parts[0])
-			desc := len(parts) > 1 && strings.ToLower(parts[1]) == "desc"
-			orderByFields = append(orderByFields, ast.OrderByField{Field: field, Desc: desc})
 		}
 	}
 
-	// Determine base query for SELECT ... FROM ...
-	baseQueryEnd := len(query)
-	if groupIdx != -1 {
-		baseQueryEnd = groupIdx
-	} else if orderIdx != -1 {
-		baseQueryEnd = orderIdx
-	} else if limitIdx != -1 {
-		baseQueryEnd = limitIdx
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		if err := p.parseOrderBy(selectAST); err != nil {
+			return nil, err
+		}
 	}
-	baseQuery := query[:baseQueryEnd]
 
-	selectAST, err := parseCoreSelect(baseQuery)
-	if err != nil {
-		return nil, err
+	if p.acceptKeyword("LIMIT") {
+		selectAST.Limit, err = p.parseInt("LIMIT")
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	selectAST.GroupBy = groupByFields
-	selectAST.OrderBy = orderByFields
-	selectAST.Limit = limit
-
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
 	return selectAST, nil
 }
 
-func parseCoreSelect(query string) (*ast.SelectQueryAST, error) {
-	lowerQuery := strings.ToLower(query)
-	fromIdx := strings.Index(lowerQuery, "from")
-	if fromIdx == -1 {
-		return nil, fmt.Errorf("❌ Missing FROM clause")
+// parseSelectList parses "*" or a comma-separated list of fields, JSON
+// path expressions and aggregate calls.
+func (p *dqlParser) parseSelectList(selectAST *ast.SelectQueryAST) error {
+	if p.acceptPunct("*") {
+		selectAST.Fields = []string{"*"}
+		return nil
 	}
 
-	selectPart := strings.TrimSpace(query[6:fromIdx])
-	fromRest := strings.TrimSpace(query[fromIdx+4:])
-
-	fields := []string{}
-	aggregates := []ast.AggregateFunc{}
-
-	selectParts := strings.Split(selectPart, ",")
-	for _, part := range selectParts {
-		part = strings.TrimSpace(part)
-		if matches := aggregateRegex.FindStringSubmatch(part); len(matches) == 3 {
-			funcName := strings.ToUpper(matches[1])
-			fieldName := matches[2]
-			aggregates = append(aggregates, ast.AggregateFunc{Func: funcName, Field: fieldName})
+	for {
+		if fn, field, ok, err := p.parseAggregateCall(); err != nil {
+			return err
+		} else if ok {
+			selectAST.Aggregates = append(selectAST.Aggregates, ast.AggregateFunc{Func: fn, Field: field})
 		} else {
-			fields = append(fields, strings.ToLower(part))
+			field, err := p.parseFieldRef("field name")
+			if err != nil {
+				return err
+			}
+			selectAST.Fields = append(selectAST.Fields, field)
 		}
-	}
 
-	tableName, conditions, err := parseFromWhere(fromRest)
-	if err != nil {
-		return nil, err
+		if !p.acceptPunct(",") {
+			return nil
+		}
 	}
-
-	return &ast.SelectQueryAST{
-		Fields:     fields,
-		Table:      tableName,
-		Conditions: conditions,
-		Aggregates: aggregates,
-	}, nil
 }
 
-/*func parseCoreSelect(query string) (*ast.SelectQueryAST, error) {
-	lowerQuery := strings.ToLower(query)
-	fromIdx := strings.Index(lowerQuery, "from")
-	if fromIdx == -1 {
-		return nil, fmt.Errorf("❌ Missing FROM clause")
+// parseAggregateCall parses FUNC(field) or FUNC(*) when the current token
+// names an aggregate function followed by '('. ok is false otherwise.
+func (p *dqlParser) parseAggregateCall() (fn, field string, ok bool, err error) {
+	tok := p.peek()
+	fn = strings.ToUpper(tok.Value)
+	if tok.Kind != TokenIdent || !aggregateFuncs[fn] || p.peekAt(1).Kind != TokenPunct || p.peekAt(1).Value != "(" {
+		return "", "", false, nil
 	}
+	p.next()
+	p.next()
 
-	selectPart := strings.TrimSpace(query[6:fromIdx])
-	fromRest := strings.TrimSpace(query[fromIdx+4:])
+	if p.acceptPunct("*") {
+		field = "*"
+	} else if field, err = p.parseFieldRef("field in " + fn); err != nil {
+		return "", "", false, err
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return "", "", false, err
+	}
+	return fn, field, true, nil
+}
 
-	fields := []string{}
-	aggregates := []ast.AggregateFunc{}
+// parseOrderBy parses TOPOLOGICAL or the comma-separated ORDER BY items.
+// Aggregate items go to OrderByAgg, plain fields to OrderBy.
+func (p *dqlParser) parseOrderBy(selectAST *ast.SelectQueryAST) error {
+	if p.isWord("TOPOLOGICAL") {
+		p.next()
+		selectAST.OrderByTopo = true
+		return nil
+	}
 
-	selectParts := strings.Split(selectPart, ",")
-	for _, part := range selectParts {
-		part = strings.TrimSpace(part)
+	for {
+		fn, field, isAgg, err := p.parseAggregateCall()
+		if err != nil {
+			return err
+		}
+		if !isAgg {
+			if field, err = p.parseFieldRef("ORDER BY field"); err != nil {
+				return err
+			}
+		}
 
-		// Check for aggregate
-		if matches := aggregateRegex.FindStringSubmatch(part); len(matches) == 3 {
-			funcName := strings.ToUpper(matches[1])
-			fieldName := matches[2]
+		desc := false
+		if p.acceptKeyword("DESC") {
+			desc = true
+		} else {
+			p.acceptKeyword("ASC")
+		}
 
-			aggregates = append(aggregates, ast.AggregateFunc{Func: funcName, Field: fieldName})
-			fields = append(fields, fmt.Sprintf("%s(%s)", funcName, fieldName)) // ADD raw aggregate string to fields
+		if isAgg {
+			selectAST.OrderByAgg = append(selectAST.OrderByAgg, ast.AggregateOrder{Func: fn, Field: field, Desc: desc})
 		} else {
-			fields = append(fields, strings.ToLower(part))
+			selectAST.OrderBy = append(selectAST.OrderBy, ast.OrderByField{Field: field, Desc: desc})
 		}
-	}
 
-	tableName, conditions, err := parseFromWhere(fromRest)
-	if err != nil {
-		return nil, err
+		if !p.acceptPunct(",") {
+			return nil
+		}
 	}
-
-	return &ast.SelectQueryAST{
-		Fields:     fields,
-		Table:      tableName,
-		Conditions: conditions,
-		Aggregates: aggregates,
-	}, nil
 }
-*/
@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"dagenie/internal/dql/ast"
+	"fmt"
+)
+
+// ParseCreateTableToAST parses
+//
+//	CREATE TABLE name [(column TYPE [NOT NULL] [DEFAULT value], ...)]
+func ParseCreateTableToAST(query string) (*ast.CreateTableAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("CREATE") {
+		return nil, fmt.Errorf("❌ Not a CREATE TABLE query")
+	}
+	if err := p.expectWord("TABLE"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectIdent("table name")
+	if err != nil {
+		return nil, err
+	}
+	result := &ast.CreateTableAST{Name: name}
+	if p.acceptPunct("(") {
+		for {
+			col, err := p.parseColumnDef()
+			if err != nil {
+				return nil, err
+			}
+			result.Columns = append(result.Columns, col)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ParseDropTableToAST parses
+//
+//	DROP TABLE name
+func ParseDropTableToAST(query string) (*ast.DropTableAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("DROP") {
+		return nil, fmt.Errorf("❌ Not a DROP TABLE query")
+	}
+	if err := p.expectWord("TABLE"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectIdent("table name")
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return &ast.DropTableAST{Name: name}, nil
+}
+
+// ParseAlterTableToAST parses
+//
+//	ALTER TABLE name ADD [COLUMN] column TYPE [NOT NULL] [DEFAULT value]
+//	ALTER TABLE name DROP [COLUMN] column
+func ParseAlterTableToAST(query string) (*ast.AlterTableAST, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptWord("ALTER") {
+		return nil, fmt.Errorf("❌ Not an ALTER TABLE query")
+	}
+	if err := p.expectWord("TABLE"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectIdent("table name")
+	if err != nil {
+		return nil, err
+	}
+	result := &ast.AlterTableAST{Name: name}
+
+	switch {
+	case p.acceptWord("ADD"):
+		p.acceptWord("COLUMN")
+		result.Action = "ADD"
+		if result.Column, err = p.parseColumnDef(); err != nil {
+			return nil, err
+		}
+	case p.acceptWord("DROP"):
+		p.acceptWord("COLUMN")
+		result.Action = "DROP"
+		if result.Column.Name, err = p.expectIdent("column name"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf(p.peek(), "Expected ADD or DROP, got %s", p.peek())
+	}
+
+	if err := p.expectEnd(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseColumnDef parses column TYPE [NOT NULL] [DEFAULT value]. The type
+// is only checked by the executor.
+func (p *dqlParser) parseColumnDef() (ast.ColumnDef, error) {
+	var col ast.ColumnDef
+	var err error
+	if col.Name, err = p.expectIdent("column name"); err != nil {
+		return col, err
+	}
+	if col.Type, err = p.expectIdent("column type"); err != nil {
+		return col, err
+	}
+	for {
+		switch {
+		case p.acceptKeyword("NOT"):
+			if err := p.expectKeyword("NULL"); err != nil {
+				return col, err
+			}
+			col.NotNull = true
+		case p.acceptWord("DEFAULT"):
+			value, err := p.parseValue()
+			if err != nil {
+				return col, err
+			}
+			col.Default = nil // DEFAULT NULL is the same as no default
+			if !value.IsNull() {
+				col.Default = &value.Text
+			}
+		default:
+			return col, nil
+		}
+	}
+}
@@ -3,7 +3,6 @@ package parser
 import (
 	"dagenie/internal/dql/ast"
 	"fmt"
-	"strings"
 )
 
 type UpdateQuery struct {
@@ -12,66 +11,131 @@ type UpdateQuery struct {
 	Conditions map[string]string
 }
 
+// ParseUpdateToAST parses
+//
+//	UPDATE <table> SET field = value [, ...] [WHERE ...] [RETURNING ...]
 func ParseUpdateToAST(query string) (*ast.UpdateQueryAST, error) {
-	// Trim and lowercase
-	query = strings.TrimSpace(query)
-	lower := strings.ToLower(query)
-
-	if !strings.HasPrefix(lower, "update") {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+	if !p.acceptKeyword("UPDATE") {
 		return nil, fmt.Errorf("❌ Not an UPDATE query")
 	}
 
-	setIdx := strings.Index(lower, "set")
-	whereIdx := strings.Index(lower, "where")
+	table, err := p.expectIdent("table name")
+	if err != nil {
+		return nil, err
+	}
 
-	if setIdx == -1 {
-		return nil, fmt.Errorf("❌ Missing SET clause")
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
 	}
 
-	table := strings.TrimSpace(query[6:setIdx])
-	if table == "" {
-		return nil, fmt.Errorf("❌ Missing table name")
+	// Parse SET
+	setFields, err := p.parseSetList(false)
+	if err != nil {
+		return nil, err
 	}
 
-	var setPart, wherePart string
-	if whereIdx != -1 {
-		setPart = query[setIdx+3 : whereIdx]
-		wherePart = query[whereIdx+5:]
-	} else {
-		setPart = query[setIdx+3:]
+	// Parse WHERE
+	where, err := p.parseOptionalWhere()
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse SET
-	setFields := make(map[string]string)
-	assignments := strings.Split(setPart, ",")
-	for _, assign := range assignments {
-		kv := strings.SplitN(assign, "=", 2)
-		if len(kv) != 2 {
-			return nil, fmt.Errorf("❌ Invalid SET clause: %s", assign)
-		}
-		field := strings.ToLower(strings.TrimSpace(kv[0]))
-		value := strings.Trim(strings.TrimSpace(kv[1]), `"'`)
-		setFields[field] = value
+	returning, err := p.parseOptionalReturning()
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse WHERE
-	where := make(map[string]string)
-	if wherePart != "" {
-		conditions := strings.Split(wherePart, "and")
-		for _, cond := range conditions {
-			kv := strings.SplitN(cond, "=", 2)
-			if len(kv) != 2 {
-				return nil, fmt.Errorf("❌ Invalid WHERE clause: %s", cond)
-			}
-			field := strings.ToLower(strings.TrimSpace(kv[0]))
-			value := strings.Trim(strings.TrimSpace(kv[1]), `"'`)
-			where[field] = value
-		}
+	if err := p.expectEnd(); err != nil {
+		return nil, err
 	}
 
 	return &ast.UpdateQueryAST{
-		Table:     strings.ToLower(table),
+		Table:     table,
 		SetFields: setFields,
-		Where:     where, // ✅ Now exists
+		Where:     where,
+		Returning: returning,
 	}, nil
 }
+
+// parseSetList parses field = value [, ...] after SET, where a value may
+// be a literal or a JSON_SET call. With allowExcluded, as in INSERT ... ON
+// CONFLICT DO UPDATE, a value may also be EXCLUDED.field, the field of the
+// row that was being inserted. A field may only be set once.
+func (p *dqlParser) parseSetList(allowExcluded bool) ([]ast.Assignment, error) {
+	var assignments []ast.Assignment
+	seen := make(map[string]bool)
+	for {
+		tok := p.peek()
+		field, err := p.expectIdent("field name in SET")
+		if err != nil {
+			return nil, err
+		}
+		if seen[field] {
+			return nil, p.errorf(tok, "Field %s is set twice", field)
+		}
+		seen[field] = true
+		if tok := p.next(); tok.Kind != TokenOperator || tok.Value != "=" {
+			return nil, p.errorf(tok, "Expected '=' after %s, got %s", field, tok)
+		}
+
+		assignment := ast.Assignment{Field: field}
+		if allowExcluded && p.isWord("EXCLUDED") && p.peekAt(1).Kind == TokenPunct && p.peekAt(1).Value == "." {
+			p.next()
+			p.next()
+			if assignment.Excluded, err = p.expectIdent("field name after EXCLUDED."); err != nil {
+				return nil, err
+			}
+		} else if p.isWord("JSON_SET") && p.peekAt(1).Kind == TokenPunct && p.peekAt(1).Value == "(" {
+			set, err := p.parseJSONSet()
+			if err != nil {
+				return nil, err
+			}
+			assignment.Value = ast.Literal{Kind: ast.LiteralJSONSet, Set: set}
+		} else if assignment.Value, err = p.parseValue(); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+
+		if !p.acceptPunct(",") {
+			break
+		}
+	}
+	return assignments, nil
+}
+
+// parseJSONSet parses JSON_SET(field, '$.path', value [, '$.path', value ...]).
+func (p *dqlParser) parseJSONSet() (*ast.JSONSet, error) {
+	p.next()
+	p.next()
+	field, err := p.expectIdent("field name in JSON_SET")
+	if err != nil {
+		return nil, err
+	}
+	set := &ast.JSONSet{Field: field}
+	for p.acceptPunct(",") {
+		path, err := p.parseJSONPath("',' in JSON_SET")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(","); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		set.Paths = append(set.Paths, path)
+		set.Values = append(set.Values, value)
+	}
+	if len(set.Paths) == 0 {
+		return nil, p.errorf(p.peek(), "Expected ',' and a JSON path in JSON_SET, got %s", p.peek())
+	}
+	if err := p.expectPunct(")"); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
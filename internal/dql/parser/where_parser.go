@@ -2,107 +2,48 @@ package parser
 
 import (
 	"dagenie/internal/dql/ast"
-	"fmt"
-	"regexp"
-	"strings"
 )
 
-// ------------------------------------------
-// WHERE Parser State
-// ------------------------------------------
-type whereParser struct {
-	tokens []string
-	pos    int
-}
-
-// Define this at the top of your file (outside any function)
-var whereTokenPattern = regexp.MustCompile(`\s*(\(|\)|\bAND\b|\bOR\b|\bNOT\b|<=|>=|!=|=|<|>|\bLIKE\b|'[^']*'|"[^"]*"|[^\s()=<>!]+)\s*`)
-
-// Tokenize WHERE clause into individual tokens
-func tokenizeWhere(input string) []string {
-	matches := whereTokenPattern.FindAllStringSubmatch(input, -1)
-	var tokens []string
-	for _, match := range matches {
-		token := strings.TrimSpace(match[1])
-		if token != "" {
-			tokens = append(tokens, token)
-		}
-	}
-	return tokens
-}
-
+// ParseWhereClause parses "<table> [WHERE <expr>]" into the table name and
+// a logical expression tree (nil when there is no WHERE clause).
 func ParseWhereClause(fromRest string) (string, ast.LogicalNode, error) {
-	fromRest = strings.TrimSpace(fromRest)
-	lower := strings.ToLower(fromRest)
-	whereIdx := strings.Index(lower, "where")
-
-	var tableName, wherePart string
-	if whereIdx != -1 {
-		tableName = strings.TrimSpace(fromRest[:whereIdx])
-		wherePart = strings.TrimSpace(fromRest[whereIdx+5:])
-	} else {
-		tableName = fromRest
-	}
-
-	if tableName == "" {
-		return "", nil, fmt.Errorf("❌ Missing table name after FROM")
-	}
-
-	if wherePart == "" {
-		return strings.ToLower(tableName), nil, nil
-	}
-
-	tokens := tokenizeWhere(wherePart)
-	fmt.Printf("[DEBUG] Tokens: %v\n", tokens)
-
-	logicalExpr, err := parseWhereTokens(tokens)
+	p, err := newParser(fromRest)
 	if err != nil {
 		return "", nil, err
 	}
-	fmt.Printf("[DEBUG] Parsed LogicalExpr: %+v\n", logicalExpr)
-
-	return strings.ToLower(tableName), logicalExpr, nil
-}
 
-func parseWhereTokens(tokens []string) (ast.LogicalNode, error) {
-	p := &whereParser{tokens: tokens}
-	expr, err := p.parseExpression()
+	tableName, err := p.expectIdent("table name after FROM")
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	if p.pos < len(p.tokens) {
-		return nil, fmt.Errorf("❌ Unexpected token after WHERE clause: %s", p.peek())
+	where, err := p.parseOptionalWhere()
+	if err != nil {
+		return "", nil, err
 	}
 
-	return expr, nil
-}
-
-func (p *whereParser) peek() string {
-	if p.pos >= len(p.tokens) {
-		return ""
+	if err := p.expectEnd(); err != nil {
+		return "", nil, err
 	}
-	return strings.ToUpper(p.tokens[p.pos])
+	return tableName, where, nil
 }
 
-func (p *whereParser) consume() string {
-	if p.pos >= len(p.tokens) {
-		return ""
+// parseOptionalWhere parses a WHERE clause if one follows.
+func (p *dqlParser) parseOptionalWhere() (ast.LogicalNode, error) {
+	if !p.acceptKeyword("WHERE") {
+		return nil, nil
 	}
-	token := p.tokens[p.pos]
-	p.pos++
-	return token
+	return p.parseExpression()
 }
 
 // parseExpression: handles OR level
-func (p *whereParser) parseExpression() (ast.LogicalNode, error) {
+func (p *dqlParser) parseExpression() (ast.LogicalNode, error) {
 	left, err := p.parseAnd()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.peek() == "OR" {
-		p.consume()
+	for p.acceptKeyword("OR") {
 		right, err := p.parseAnd()
 		if err != nil {
 			return nil, err
@@ -113,14 +54,13 @@ func (p *whereParser) parseExpression() (ast.LogicalNode, error) {
 }
 
 // parseAnd: handles AND level
-func (p *whereParser) parseAnd() (ast.LogicalNode, error) {
+func (p *dqlParser) parseAnd() (ast.LogicalNode, error) {
 	left, err := p.parseNot()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.peek() == "AND" {
-		p.consume()
+	for p.acceptKeyword("AND") {
 		right, err := p.parseNot()
 		if err != nil {
 			return nil, err
@@ -131,10 +71,9 @@ func (p *whereParser) parseAnd() (ast.LogicalNode, error) {
 }
 
 // parseNot: handles NOT or passes to parseAtom
-func (p *whereParser) parseNot() (ast.LogicalNode, error) {
-	if p.peek() == "NOT" {
-		p.consume()
-		expr, err := p.parseAtom()
+func (p *dqlParser) parseNot() (ast.LogicalNode, error) {
+	if p.acceptKeyword("NOT") {
+		expr, err := p.parseNot()
 		if err != nil {
 			return nil, err
 		}
@@ -144,41 +83,130 @@ func (p *whereParser) parseNot() (ast.LogicalNode, error) {
 }
 
 // parseAtom: parses (expr) or condition
-func (p *whereParser) parseAtom() (ast.LogicalNode, error) {
-	token := p.peek()
-
-	if token == "(" {
-		p.consume()
+func (p *dqlParser) parseAtom() (ast.LogicalNode, error) {
+	if p.acceptPunct("(") {
 		expr, err := p.parseExpression()
 		if err != nil {
 			return nil, err
 		}
-		if p.consume() != ")" {
-			return nil, fmt.Errorf("❌ Expected ')' after expression")
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
 		}
 		return expr, nil
 	}
 
-	// Parse condition: field = value
-	field := p.consume()
-	if field == "" {
-		return nil, fmt.Errorf("❌ Expected field in condition")
+	field, err := p.parseFieldRef("field in condition")
+	if err != nil {
+		return nil, err
 	}
+	return p.parsePredicate(field)
+}
 
-	operator := p.consume()
-	if operator != "=" {
-		return nil, fmt.Errorf("❌ Only '=' operator supported (got '%s')", operator)
+// parsePredicate parses everything after the field name of a condition:
+//
+//	op value | [NOT] IN (v, ...) | [NOT] BETWEEN a AND b
+//	| [NOT] LIKE/ILIKE pattern | [NOT] CONTAINS value | IS [NOT] NULL/EMPTY
+func (p *dqlParser) parsePredicate(field string) (*ast.ConditionNode, error) {
+	cond := &ast.ConditionNode{Field: field}
+
+	if p.acceptKeyword("IS") {
+		negate := p.acceptKeyword("NOT")
+		if !p.acceptKeyword("NULL") && !p.acceptWord("EMPTY") {
+			return nil, p.errorf(p.peek(), "Expected NULL or EMPTY after IS, got %s", p.peek())
+		}
+		cond.Operator = "IS NULL"
+		if negate {
+			cond.Operator = "IS NOT NULL"
+		}
+		return cond, nil
 	}
 
-	val := p.consume()
-	if val == "" {
-		return nil, fmt.Errorf("❌ Missing value after '=' for field '%s'", field)
+	prefix := ""
+	if p.acceptKeyword("NOT") {
+		prefix = "NOT "
 	}
-	val = strings.Trim(val, `"'`) // remove quotes
 
-	return &ast.ConditionNode{
-		Field:    strings.ToLower(field),
-		Operator: "=",
-		Value:    val,
-	}, nil
+	switch {
+	case p.acceptKeyword("IN"):
+		cond.Operator = prefix + "IN"
+		if err := p.expectPunct("("); err != nil {
+			return nil, err
+		}
+		for {
+			val, err := p.parseConditionValue()
+			if err != nil {
+				return nil, err
+			}
+			cond.Values = append(cond.Values, val)
+			if !p.acceptPunct(",") {
+				break
+			}
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return cond, nil
+
+	case p.acceptKeyword("BETWEEN"):
+		cond.Operator = prefix + "BETWEEN"
+		low, err := p.parseConditionValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseConditionValue()
+		if err != nil {
+			return nil, err
+		}
+		cond.Values = []string{low, high}
+		return cond, nil
+
+	case p.isWord("CONTAINS"):
+		p.next()
+		cond.Operator = prefix + "CONTAINS"
+		val, err := p.parseConditionValue()
+		if err != nil {
+			return nil, err
+		}
+		cond.Value = val
+		return cond, nil
+
+	case p.isKeyword("LIKE") || p.isKeyword("ILIKE"):
+		cond.Operator = prefix + p.next().Value
+		val, err := p.parseConditionValue()
+		if err != nil {
+			return nil, err
+		}
+		cond.Value = val
+		return cond, nil
+	}
+
+	opTok := p.peek()
+	if prefix != "" {
+		return nil, p.errorf(opTok, "Expected IN, BETWEEN, LIKE, ILIKE or CONTAINS after NOT, got %s", opTok)
+	}
+	if opTok.Kind != TokenOperator || !comparisonOps[opTok.Value] {
+		return nil, p.errorf(opTok, "Expected comparison operator after %s, got %s", field, opTok)
+	}
+	p.next()
+
+	val, err := p.parseConditionValue()
+	if err != nil {
+		return nil, err
+	}
+	cond.Operator = opTok.Value
+	cond.Value = val
+	return cond, nil
+}
+
+// comparisonOps are the binary operators accepted in conditions.
+var comparisonOps = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// parseConditionValue accepts a literal or, for backwards compatibility
+// with unquoted values such as status = done, a bare word. TRUE and FALSE
+// are lower-cased.
+func (p *dqlParser) parseConditionValue() (string, error) {
+	return p.parseLiteral()
 }
@@ -0,0 +1,272 @@
+package dql
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"dagenie/internal/dql/executor"
+	"dagenie/internal/dql/parser"
+	"dagenie/internal/dql/storage"
+)
+
+// DefaultTransactionIdleTimeout is how long a transaction may wait for its
+// next statement before it is rolled back.
+const DefaultTransactionIdleTimeout = time.Minute
+
+// TransactionIdleTimeout is how long an open transaction may stay idle
+// between statements. It is then rolled back and releases the writer lock,
+// so a session that ran BEGIN and went quiet does not block other writers.
+// 0 disables the timeout.
+var TransactionIdleTimeout = DefaultTransactionIdleTimeout
+
+// defaultDBName is how a session names the database the server was
+// started with. USE default only switches to it when the catalog has no
+// database of that name; see Session.Execute.
+const defaultDBName = "default"
+
+// Session is the state of one client connection: the database it is
+// using and its open transaction, if any. Databases other than the server
+// default are borrowed from the Manager and returned when the session
+// switches away or closes.
+type Session struct {
+	manager   *Manager
+	defaultDB *storage.Database
+	onDefault bool // using defaultDB rather than the catalog database name
+	name      string
+	db        *storage.Database
+	tx        *executor.Transaction
+}
+
+// NewSession starts a session on the server's default database.
+func NewSession(manager *Manager, defaultDB *storage.Database) *Session {
+	return &Session{manager: manager, defaultDB: defaultDB, onDefault: true, db: defaultDB}
+}
+
+// Database returns the name of the database the session is using.
+func (s *Session) Database() string {
+	if s.onDefault {
+		return defaultDBName
+	}
+	return s.name
+}
+
+// InTransaction reports whether the session has an open transaction.
+func (s *Session) InTransaction() bool {
+	return s.tx != nil
+}
+
+// Close rolls back an open transaction and releases the database the
+// session is using.
+func (s *Session) Close() {
+	if s.tx != nil {
+		s.tx.Resume()
+		s.tx.Rollback()
+		s.tx = nil
+	}
+	s.switchToDefault()
+}
+
+// switchTo makes a catalog database obtained from Manager.Acquire the
+// current one.
+func (s *Session) switchTo(name string, db *storage.Database) {
+	s.release()
+	s.onDefault = false
+	s.name = name
+	s.db = db
+}
+
+// switchToDefault makes the server's database the current one.
+func (s *Session) switchToDefault() {
+	s.release()
+	s.onDefault = true
+	s.name = ""
+	s.db = s.defaultDB
+}
+
+// release returns the current catalog database to the manager.
+func (s *Session) release() {
+	if !s.onDefault {
+		s.manager.Release(s.name)
+	}
+}
+
+// Execute runs one statement: database statements (CREATE, USE, SHOW and
+// DROP DATABASE) and transaction control (BEGIN, COMMIT and ROLLBACK) are
+// handled here, everything else goes to ExecuteDQL on the current
+// database, or into the open transaction.
+func (s *Session) Execute(query string) (*executor.ResultSet, error) {
+	start := time.Now()
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	lower := strings.ToLower(query)
+	words := strings.Join(strings.Fields(lower), " ")
+
+	// An open transaction waits for its next statement on the idle timer
+	if s.tx != nil {
+		if err := s.tx.Resume(); err != nil {
+			s.tx = nil
+			if words == "rollback" || words == "rollback transaction" {
+				return &executor.ResultSet{Message: "🔄 Transaction rolled back", Elapsed: time.Since(start)}, nil
+			}
+			return nil, &QueryError{Code: CodeTxAborted, Err: err}
+		}
+	}
+	defer func() {
+		if s.tx != nil {
+			s.tx.Suspend(TransactionIdleTimeout)
+		}
+	}()
+
+	// Statements that cannot be undone are refused, leaving the
+	// transaction open
+	if s.tx != nil {
+		if err := checkTransactional(query, append([]string{"create database", "drop database", "use"}, tableStatements...)); err != nil {
+			return nil, err
+		}
+	}
+
+	var result *executor.ResultSet
+	switch {
+	// BEGIN
+	case words == "begin" || words == "begin transaction" || words == "start transaction":
+		if s.tx != nil {
+			return nil, fmt.Errorf("❌ A transaction is already open")
+		}
+		s.tx = executor.BeginTransaction(s.db)
+		result = &executor.ResultSet{Message: "✅ Transaction started"}
+
+	// COMMIT
+	case words == "commit" || words == "commit transaction":
+		if s.tx == nil {
+			return nil, fmt.Errorf("❌ No transaction is open")
+		}
+		writes := s.tx.Writes()
+		err := s.tx.Commit()
+		s.tx = nil
+		if err != nil {
+			return nil, err
+		}
+		result = &executor.ResultSet{RowsAffected: writes, Message: fmt.Sprintf("✅ Transaction committed (%d write(s))", writes)}
+
+	// ROLLBACK
+	case words == "rollback" || words == "rollback transaction":
+		if s.tx == nil {
+			return nil, fmt.Errorf("❌ No transaction is open")
+		}
+		s.tx.Rollback()
+		s.tx = nil
+		result = &executor.ResultSet{Message: "🔄 Transaction rolled back"}
+
+	// CREATE DATABASE
+	case strings.HasPrefix(lower, "create database"):
+		createAST, err := parser.ParseCreateDatabaseToAST(query)
+		if err != nil {
+			return nil, parseError("CREATE DATABASE", err)
+		}
+		owner := createAST.Owner
+		if owner == "" {
+			owner = defaultOwner()
+		}
+		if err := s.manager.Create(createAST.Name, owner, createAST.Options); err != nil {
+			return nil, err
+		}
+		result = &executor.ResultSet{Message: fmt.Sprintf("✅ Database '%s' created", createAST.Name)}
+
+	// USE DB
+	case lower == "use" || strings.HasPrefix(lower, "use "):
+		dbName := strings.TrimSpace(query[len("use"):]) // Use original case for name
+		if dbName == "" {
+			return nil, fmt.Errorf("❌ No database specified")
+		}
+		// A catalog database named default takes precedence over the
+		// server's database
+		if dbName == defaultDBName && !s.manager.Exists(dbName) {
+			s.switchToDefault()
+		} else if s.onDefault || dbName != s.name {
+			db, err := s.manager.Acquire(dbName)
+			if err != nil {
+				return nil, err
+			}
+			s.switchTo(dbName, db)
+		}
+		result = &executor.ResultSet{Message: fmt.Sprintf("✅ Using database '%s'", dbName)}
+
+	// SHOW DATABASES
+	case strings.HasPrefix(lower, "show databases"):
+		infos, err := s.manager.List()
+		if err != nil {
+			return nil, err
+		}
+		result = &executor.ResultSet{Columns: []executor.Column{
+			{Name: "database", Type: executor.TypeString},
+			{Name: "created_at", Type: executor.TypeString},
+			{Name: "owner", Type: executor.TypeString},
+			{Name: "options", Type: executor.TypeJSON},
+			{Name: "size_bytes", Type: executor.TypeInt},
+		}}
+		for _, info := range infos {
+			options, _ := json.Marshal(info.Options)
+			if info.Options == nil {
+				options = []byte("{}")
+			}
+			result.Rows = append(result.Rows, []interface{}{
+				info.Name, info.CreatedAt.Format(time.RFC3339), info.Owner, json.RawMessage(options), info.SizeBytes,
+			})
+		}
+
+	// DROP DATABASE
+	case strings.HasPrefix(lower, "drop database"):
+		dbName := strings.TrimSpace(query[len("drop database"):])
+		if !s.onDefault && dbName == s.name {
+			return nil, fmt.Errorf("❌ Database '%s' is the current database, USE another one first", dbName)
+		}
+		if err := s.manager.Drop(dbName); err != nil {
+			return nil, err
+		}
+		result = &executor.ResultSet{Message: fmt.Sprintf("🗑️ Database '%s' deleted", dbName)}
+
+	// Pass to existing DQL (SELECT, INSERT, etc.)
+	default:
+		if s.tx == nil {
+			return ExecuteDQL(s.db, query)
+		}
+		savepoint := s.tx.Savepoint()
+		result, err := ExecuteDQLInTransaction(s.db, s.tx, query)
+		if err != nil {
+			if ErrorCode(err) == CodeConflict {
+				return nil, s.abort(err)
+			}
+			// Drop what the statement wrote before failing; the
+			// transaction stays open
+			s.tx.RollbackTo(savepoint)
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
+
+// abort rolls back the open transaction after one of its statements
+// could not get the writer lock. Sending the statement again would run
+// it outside the transaction, so the error is not retryable.
+func (s *Session) abort(err error) error {
+	s.tx.Rollback()
+	s.tx = nil
+	return &QueryError{Code: CodeTxAborted, Err: fmt.Errorf("%w\n🔄 Transaction aborted, restart from BEGIN", err)}
+}
+
+// defaultOwner is the owner recorded for databases created without OWNER:
+// the user running the server.
+func defaultOwner() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,305 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"dagenie/internal/dagdb"
+)
+
+// DefaultTable is the built-in table every database has. Its tasks are
+// stored under their own DAG IDs, so databases created before tables
+// existed keep working unchanged; see Table.
+const DefaultTable = "dag"
+
+// tablesDir is the directory, inside a database directory, that holds the
+// table catalog and the write journal.
+const tablesDir = "tables"
+
+// TableInfo is the catalog entry of one table.
+type TableInfo struct {
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"created_at"`
+	Columns   []ColumnDef `json:"columns,omitempty"`
+}
+
+// Database is one DAGenie database: the built-in dag table plus any tables
+// created with CREATE TABLE, all kept in a single DAGDB. Each table has its
+// own key space within it; see Table.
+//
+// Statements hold the read side of the statement lock for their whole run
+// (see RLock); DROP TABLE and ALTER TABLE take the write side, so they
+// wait for running statements instead of changing tasks or the schema
+// under them.
+//
+// Writes additionally go through a single writer lock (see LockWriter),
+// held by a write statement or an open transaction from its first write
+// until it commits or rolls back. Transactions keep their writes to
+// themselves until Commit, which applies them under the commit lock;
+// read-only statements hold its read side (see RLockCommits), so they see
+// a commit in full or not at all.
+type Database struct {
+	stmt   sync.RWMutex
+	commit sync.RWMutex
+	writer chan struct{}
+
+	mu     sync.Mutex
+	dir    string
+	main   *dagdb.DAGDB
+	tables map[string]*TableInfo
+}
+
+type tableCatalog struct {
+	Version int          `json:"version"`
+	Tables  []*TableInfo `json:"tables"`
+}
+
+// OpenDatabase opens the database stored in dir.
+func OpenDatabase(dir string) (*Database, error) {
+	main, err := dagdb.OpenDAGDB(dir)
+	if err != nil {
+		return nil, err
+	}
+	d, err := NewDatabase(dir, main)
+	if err != nil {
+		main.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// NewDatabase wraps an already open DAGDB as the store of the database in
+// dir, loads its table catalog and undoes any transaction left unfinished
+// by a crash.
+func NewDatabase(dir string, main *dagdb.DAGDB) (*Database, error) {
+	d := &Database{
+		writer: make(chan struct{}, 1),
+		dir:    dir,
+		main:   main,
+		tables: make(map[string]*TableInfo),
+	}
+	data, err := os.ReadFile(d.catalogPath())
+	switch {
+	case err == nil:
+		var doc tableCatalog
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("❌ Corrupt table catalog %s: %v", d.catalogPath(), err)
+		}
+		for _, info := range doc.Tables {
+			d.tables[info.Name] = info
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("❌ Failed to read table catalog: %v", err)
+	}
+	if err := d.recoverJournal(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// RLock marks the start of a statement; see Database.
+func (d *Database) RLock() {
+	d.stmt.RLock()
+}
+
+// RUnlock marks the end of a statement.
+func (d *Database) RUnlock() {
+	d.stmt.RUnlock()
+}
+
+// RLockCommits makes a read-only statement wait for the commit being
+// applied, if any, and keeps the next one from starting until
+// RUnlockCommits.
+func (d *Database) RLockCommits() {
+	d.commit.RLock()
+}
+
+// RUnlockCommits ends a read-only statement.
+func (d *Database) RUnlockCommits() {
+	d.commit.RUnlock()
+}
+
+// WriterLockTimeout is how long a write waits for the writer lock before
+// failing with ErrWriteConflict.
+const WriterLockTimeout = 2 * time.Second
+
+// ErrWriteConflict is returned when another transaction holds the writer
+// lock for too long. The statement can be retried.
+var ErrWriteConflict = errors.New("❌ Write conflict: another transaction is writing to this database, retry later")
+
+// LockWriter takes the writer lock of the database, waiting at most
+// WriterLockTimeout. Only one statement or transaction writes at a time,
+// so the tasks it validated against cannot change under it.
+func (d *Database) LockWriter() error {
+	select {
+	case d.writer <- struct{}{}:
+		return nil
+	case <-time.After(WriterLockTimeout):
+		return ErrWriteConflict
+	}
+}
+
+// UnlockWriter releases the writer lock.
+func (d *Database) UnlockWriter() {
+	<-d.writer
+}
+
+// Main returns the DAGDB holding every table of the database.
+func (d *Database) Main() *dagdb.DAGDB {
+	return d.main
+}
+
+func (d *Database) catalogPath() string {
+	return filepath.Join(d.dir, tablesDir, "catalog.json")
+}
+
+// saveLocked writes the table catalog atomically.
+func (d *Database) saveLocked() error {
+	doc := tableCatalog{Version: 1, Tables: d.listLocked()}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("❌ Failed to encode table catalog: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(d.dir, tablesDir), 0755); err != nil {
+		return fmt.Errorf("❌ Failed to write table catalog: %v", err)
+	}
+	tmp := d.catalogPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("❌ Failed to write table catalog: %v", err)
+	}
+	if err := os.Rename(tmp, d.catalogPath()); err != nil {
+		return fmt.Errorf("❌ Failed to write table catalog: %v", err)
+	}
+	return nil
+}
+
+func (d *Database) listLocked() []*TableInfo {
+	infos := make([]*TableInfo, 0, len(d.tables))
+	for _, info := range d.tables {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Table returns the named table. The dag table may have a catalog entry
+// for its columns but always exists.
+func (d *Database) Table(name string) (*Table, error) {
+	if name == DefaultTable {
+		return newTable(d.main, name), nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; !ok {
+		return nil, fmt.Errorf("❌ Unknown table: %s", name)
+	}
+	return newTable(d.main, name), nil
+}
+
+// View returns the named table as a transaction sees it: the committed
+// tasks with the pending writes of the transaction on top.
+func (d *Database) View(name string, pending []TaskWrite) (*Table, error) {
+	table, err := d.Table(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range pending {
+		if w.Table == name {
+			table.pending = append(table.pending, w)
+		}
+	}
+	return table, nil
+}
+
+// Tables lists the tables of the database, dag first.
+func (d *Database) Tables() []TableInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := []TableInfo{{Name: DefaultTable}}
+	if info, ok := d.tables[DefaultTable]; ok {
+		result[0] = *info
+	}
+	for _, info := range d.listLocked() {
+		if info.Name != DefaultTable {
+			result = append(result, *info)
+		}
+	}
+	return result
+}
+
+// CreateTable adds an empty table with the given user-defined columns.
+func (d *Database) CreateTable(name string, columns []ColumnDef) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.tables[name]; ok || name == DefaultTable {
+		return fmt.Errorf("❌ Table '%s' already exists", name)
+	}
+	d.tables[name] = &TableInfo{Name: name, CreatedAt: time.Now().UTC(), Columns: columns}
+	if err := d.saveLocked(); err != nil {
+		delete(d.tables, name)
+		return err
+	}
+	return nil
+}
+
+// DropTable deletes a table and its tasks. It waits for running statements
+// and open transactions to finish first. The tasks are deleted through the
+// write journal before the table leaves the catalog, so a crash halfway
+// leaves the table as it was.
+func (d *Database) DropTable(name string) error {
+	if name == DefaultTable {
+		return fmt.Errorf("❌ The built-in table '%s' cannot be dropped", DefaultTable)
+	}
+	if err := d.LockWriter(); err != nil {
+		return err
+	}
+	defer d.UnlockWriter()
+	d.stmt.Lock()
+	defer d.stmt.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, ok := d.tables[name]
+	if !ok {
+		return fmt.Errorf("❌ Unknown table: %s", name)
+	}
+	table := newTable(d.main, name)
+	tasks, err := table.ListAllTasks()
+	if err != nil {
+		return fmt.Errorf("❌ Drop table failed: %v", err)
+	}
+	values, err := table.ColumnValues()
+	if err != nil {
+		return fmt.Errorf("❌ Drop table failed: %v", err)
+	}
+	var writes []TaskWrite
+	for _, task := range tasks {
+		writes = append(writes, TaskWrite{Table: name, Kind: WriteDelete, Old: task, OldColumns: values[task.ObjectID]})
+	}
+	if err := d.writeBatch(writes); err != nil {
+		return fmt.Errorf("❌ Drop table failed: %v", err)
+	}
+	delete(d.tables, name)
+	if err := d.saveLocked(); err != nil {
+		d.tables[name] = info
+		if undoErr := d.undoBatch(writes); undoErr != nil {
+			return fmt.Errorf("%v\n%v", err, undoErr)
+		}
+		return err
+	}
+	return d.ClearJournal()
+}
+
+// Close closes the store of the database.
+func (d *Database) Close() error {
+	return d.main.Close()
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"dagenie/internal/dagdb"
+)
+
+// Kinds of TaskWrite.
+const (
+	WriteInsert = "insert"
+	WriteUpdate = "update"
+	WriteRekey  = "rekey"
+	WriteDelete = "delete"
+)
+
+// TaskWrite is one task write of a transaction: the task before and after
+// it in a table, with the values of its user-defined columns. Old is empty
+// for inserts and New is empty for deletes. A rekey replaces Old by New
+// under a new (dagid, id) key.
+type TaskWrite struct {
+	Table      string        `json:"table"`
+	Kind       string        `json:"kind"`
+	Old        dagdb.DAGTask `json:"old"`
+	New        dagdb.DAGTask `json:"new"`
+	OldColumns ColumnValues  `json:"old_columns,omitempty"`
+	NewColumns ColumnValues  `json:"new_columns,omitempty"`
+}
+
+// objectID is the ObjectID of the task a write is about.
+func (w TaskWrite) objectID() string {
+	if w.Kind == WriteInsert {
+		return w.New.ObjectID
+	}
+	return w.Old.ObjectID
+}
+
+// journalPath is the write journal of the database.
+//
+// DAGDB has no multi-key transaction, so a commit applies its writes one
+// by one and can only be undone from the old versions of the tasks. To
+// survive a crash halfway, the writes are first appended to the journal
+// with a single sync (see Commit). The journal is removed once they are
+// all applied; one that is still there when the database is opened
+// belongs to an unfinished commit, whose writes are then undone.
+//
+// Only the holder of the writer lock touches the journal.
+func (d *Database) journalPath() string {
+	return filepath.Join(d.dir, tablesDir, "journal.jsonl")
+}
+
+// Commit applies the writes of a transaction as one batch: they are
+// journaled with a single sync, applied in order under the commit lock and
+// the journal is cleared, which makes them final. The in-memory graph then
+// follows. If a write fails, those before it are undone and nothing is
+// committed.
+func (d *Database) Commit(writes []TaskWrite) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	d.commit.Lock()
+	err := d.writeBatch(writes)
+	if err == nil {
+		err = d.ClearJournal()
+	}
+	d.commit.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, w := range writes {
+		if err := d.SyncGraph(w); err != nil {
+			fmt.Printf("⚠️ Graph refresh failed for task ID=%s: %v\n", w.New.ID, err)
+		}
+	}
+	return nil
+}
+
+// writeBatch journals a batch of writes with a single sync and applies
+// them in order. Applying is not a Badger WriteBatch: DAGDB has no batch
+// write, so each task and each column record is saved on its own, and it
+// is the journal that keeps the batch all or nothing. On failure the
+// whole batch is undone; on success the journal is kept until the caller
+// clears it. The caller holds the writer
+// lock, so the tables written to cannot be dropped meanwhile.
+func (d *Database) writeBatch(writes []TaskWrite) error {
+	err := d.appendJournal(writes...)
+	for i := 0; err == nil && i < len(writes); i++ {
+		err = newTable(d.main, writes[i].Table).apply(writes[i])
+	}
+	if err != nil {
+		if undoErr := d.undoBatch(writes); undoErr != nil {
+			return fmt.Errorf("%v\n%v", err, undoErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// undoBatch reverts a batch of writes, newest first, and clears the
+// journal. Undoing a write that was never applied is harmless. On failure
+// the journal is kept, so the undo is retried when the database is next
+// opened.
+func (d *Database) undoBatch(writes []TaskWrite) error {
+	for i := len(writes) - 1; i >= 0; i-- {
+		if err := newTable(d.main, writes[i].Table).undo(writes[i]); err != nil {
+			return fmt.Errorf("❌ Failed to undo write, it will be retried when the database is next opened: %v", err)
+		}
+	}
+	return d.ClearJournal()
+}
+
+// SyncGraph brings the in-memory graph in line with a committed write.
+// A delete needs nothing here: the store drops the task from the graph
+// when Commit applies the delete.
+func (d *Database) SyncGraph(w TaskWrite) error {
+	table, err := d.Table(w.Table)
+	if err != nil {
+		return err
+	}
+	switch w.Kind {
+	case WriteInsert:
+		table.AddGraphTask(w.New)
+	case WriteUpdate, WriteRekey:
+		table.UpdateGraphTask(w.New)
+	}
+	return nil
+}
+
+// apply makes a write in the table: the task first, then its column
+// values.
+func (t *Table) apply(w TaskWrite) error {
+	var err error
+	switch w.Kind {
+	case WriteInsert, WriteUpdate:
+		err = t.SaveTask(w.New)
+	case WriteRekey:
+		err = t.UpdateTaskWithKeyChange(w.Old, w.New)
+	case WriteDelete:
+		err = t.DeleteTask(w.Old.DAGID, w.Old.ID)
+	default:
+		err = fmt.Errorf("❌ Unknown write kind: %s", w.Kind)
+	}
+	if err != nil || equalColumns(w.OldColumns, w.NewColumns) {
+		return err
+	}
+	return t.putColumns(w.objectID(), w.NewColumns)
+}
+
+// undo reverts a write in the table and the graph. It also copes with a
+// journaled write that never reached the store.
+func (t *Table) undo(w TaskWrite) error {
+	if !equalColumns(w.OldColumns, w.NewColumns) {
+		if err := t.putColumns(w.objectID(), w.OldColumns); err != nil {
+			return err
+		}
+	}
+	old := w.Old
+	switch w.Kind {
+	case WriteInsert:
+		stored, err := hasTask(t, w.New)
+		if err != nil || !stored {
+			return err
+		}
+		return t.DeleteTask(w.New.DAGID, w.New.ID)
+	case WriteUpdate:
+		if err := t.SaveTask(old); err != nil {
+			return err
+		}
+	case WriteRekey:
+		stored, err := hasTask(t, w.New)
+		if err != nil {
+			return err
+		}
+		if stored {
+			err = t.UpdateTaskWithKeyChange(w.New, old)
+		} else {
+			err = t.SaveTask(old)
+		}
+		if err != nil {
+			return err
+		}
+	case WriteDelete:
+		if err := t.SaveTask(old); err != nil {
+			return err
+		}
+		t.AddGraphTask(old)
+		return nil
+	}
+	t.UpdateGraphTask(old)
+	return nil
+}
+
+// hasTask reports whether the table holds task under its key.
+func hasTask(store *Table, task dagdb.DAGTask) (bool, error) {
+	found, err := store.QueryByObjectID(task.ObjectID)
+	if err != nil {
+		return false, err
+	}
+	for _, t := range found {
+		if t.DAGID == task.DAGID && t.ID == task.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// appendJournal appends writes to the journal and syncs it once.
+func (d *Database) appendJournal(writes ...TaskWrite) error {
+	var lines []byte
+	for _, w := range writes {
+		line, err := json.Marshal(w)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to journal write: %v", err)
+		}
+		lines = append(append(lines, line...), '\n')
+	}
+	if err := os.MkdirAll(filepath.Dir(d.journalPath()), 0755); err != nil {
+		return fmt.Errorf("❌ Failed to journal write: %v", err)
+	}
+	f, err := os.OpenFile(d.journalPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to journal write: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(lines); err != nil {
+		return fmt.Errorf("❌ Failed to journal write: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("❌ Failed to journal write: %v", err)
+	}
+	return nil
+}
+
+// ClearJournal forgets the journaled writes once their transaction has
+// committed or been rolled back.
+func (d *Database) ClearJournal() error {
+	if err := os.Remove(d.journalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("❌ Failed to clear write journal: %v", err)
+	}
+	return nil
+}
+
+// recoverJournal undoes the writes of a transaction that was cut short,
+// newest first.
+func (d *Database) recoverJournal() error {
+	f, err := os.Open(d.journalPath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("❌ Failed to read write journal: %v", err)
+	}
+	var writes []TaskWrite
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var w TaskWrite
+		if err := json.Unmarshal(scanner.Bytes(), &w); err != nil {
+			// A torn last line is a write that was never applied
+			break
+		}
+		writes = append(writes, w)
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("❌ Failed to read write journal: %v", err)
+	}
+
+	for i := len(writes) - 1; i >= 0; i-- {
+		// A table missing from the catalog was dropped after its tasks
+		// were deleted, which stands
+		if _, ok := d.tables[writes[i].Table]; !ok && writes[i].Table != DefaultTable {
+			continue
+		}
+		if err := newTable(d.main, writes[i].Table).undo(writes[i]); err != nil {
+			return fmt.Errorf("❌ Failed to undo unfinished transaction: %v", err)
+		}
+	}
+	if len(writes) > 0 {
+		fmt.Printf("🔄 Undid %d write(s) of an unfinished transaction\n", len(writes))
+	}
+	return d.ClearJournal()
+}
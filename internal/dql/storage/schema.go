@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column types of user-defined columns.
+const (
+	ColumnString    = "string"
+	ColumnInt       = "int"
+	ColumnFloat     = "float"
+	ColumnBool      = "bool"
+	ColumnTimestamp = "timestamp"
+	ColumnJSON      = "json"
+)
+
+var columnTypes = map[string]bool{
+	ColumnString: true, ColumnInt: true, ColumnFloat: true,
+	ColumnBool: true, ColumnTimestamp: true, ColumnJSON: true,
+}
+
+// ColumnDef declares a user-defined column of a table. Default holds the
+// literal text of the DEFAULT clause, nil when there is none.
+type ColumnDef struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	NotNull bool    `json:"not_null,omitempty"`
+	Default *string `json:"default,omitempty"`
+}
+
+// ValidateColumnType normalizes a column type name, accepting a few common
+// SQL spellings.
+func ValidateColumnType(name string) (string, error) {
+	t := strings.ToLower(name)
+	switch t {
+	case "text", "varchar":
+		t = ColumnString
+	case "integer", "bigint":
+		t = ColumnInt
+	case "double", "real":
+		t = ColumnFloat
+	case "boolean":
+		t = ColumnBool
+	}
+	if !columnTypes[t] {
+		return "", fmt.Errorf("❌ Unknown column type: %s", name)
+	}
+	return t, nil
+}
+
+// Columns returns the user-defined columns of a table in declaration order.
+func (d *Database) Columns(table string) ([]ColumnDef, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, ok := d.tables[table]
+	if !ok {
+		if table == DefaultTable {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("❌ Unknown table: %s", table)
+	}
+	return append([]ColumnDef(nil), info.Columns...), nil
+}
+
+// AddColumn appends a column to a table's schema. Existing tasks have no
+// value for the new column, so a NOT NULL column without a default can
+// only be added to an empty table. Values left behind under its name by
+// an interrupted DROP COLUMN are removed first, so the new column starts
+// out empty. Like DropTable, it waits for running statements and open
+// transactions to finish first.
+func (d *Database) AddColumn(table string, def ColumnDef) error {
+	if err := d.LockWriter(); err != nil {
+		return err
+	}
+	defer d.UnlockWriter()
+	d.stmt.Lock()
+	defer d.stmt.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, err := d.schemaEntryLocked(table)
+	if err != nil {
+		return err
+	}
+	for _, col := range info.Columns {
+		if col.Name == def.Name {
+			return fmt.Errorf("❌ Column '%s' already exists in table '%s'", def.Name, table)
+		}
+	}
+	store := newTable(d.main, table)
+	if def.NotNull && def.Default == nil {
+		tasks, err := store.ListAllTasks()
+		if err != nil {
+			return fmt.Errorf("❌ Task fetch error: %v", err)
+		}
+		if len(tasks) > 0 {
+			return fmt.Errorf("❌ Column '%s' is NOT NULL but has no DEFAULT for the %d existing task(s)", def.Name, len(tasks))
+		}
+	}
+	if err := store.dropColumnValues(def.Name); err != nil {
+		return fmt.Errorf("❌ Add column failed: %v", err)
+	}
+	info.Columns = append(info.Columns, def)
+	if err := d.saveLocked(); err != nil {
+		info.Columns = info.Columns[:len(info.Columns)-1]
+		return err
+	}
+	return nil
+}
+
+// DropColumn removes a column from a table's schema and deletes its
+// stored values. The schema changes first, so values a crash leaves
+// behind are never read and are cleared by the next ADD COLUMN of the
+// same name. Like DropTable, it waits for running statements and open
+// transactions to finish first.
+func (d *Database) DropColumn(table, name string) error {
+	if err := d.LockWriter(); err != nil {
+		return err
+	}
+	defer d.UnlockWriter()
+	d.stmt.Lock()
+	defer d.stmt.Unlock()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	info, err := d.schemaEntryLocked(table)
+	if err != nil {
+		return err
+	}
+	for i, col := range info.Columns {
+		if col.Name != name {
+			continue
+		}
+		previous := info.Columns
+		info.Columns = append(append([]ColumnDef(nil), previous[:i]...), previous[i+1:]...)
+		if err := d.saveLocked(); err != nil {
+			info.Columns = previous
+			return err
+		}
+		if err := newTable(d.main, table).dropColumnValues(name); err != nil {
+			return fmt.Errorf("❌ Column '%s' dropped but its values were not all deleted: %v", name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("❌ Unknown column '%s' in table '%s'", name, table)
+}
+
+// schemaEntryLocked returns the catalog entry holding a table's schema.
+// The dag table only gets an entry once it has columns.
+func (d *Database) schemaEntryLocked(table string) (*TableInfo, error) {
+	if info, ok := d.tables[table]; ok {
+		return info, nil
+	}
+	if table != DefaultTable {
+		return nil, fmt.Errorf("❌ Unknown table: %s", table)
+	}
+	info := &TableInfo{Name: DefaultTable}
+	d.tables[DefaultTable] = info
+	return info, nil
+}
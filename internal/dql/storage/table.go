@@ -0,0 +1,312 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"dagenie/internal/dagdb"
+)
+
+// keySeparator marks the DAG IDs of tasks that do not belong to the dag
+// table. It is a control character, so DAG IDs written through DQL never
+// contain it; see Table.SaveTask.
+const keySeparator = "\x1f"
+
+// tableKeyPrefix is what the DAG IDs of a user table's tasks start with
+// in the database's DAGDB: \x1f<table>\x1f.
+func tableKeyPrefix(name string) string {
+	return keySeparator + name + keySeparator
+}
+
+// columnsDAGID is the DAG ID under which the user-defined column values
+// of a table are stored: \x1f\x1f<table>, which no table prefix matches.
+func columnsDAGID(table string) string {
+	return keySeparator + keySeparator + table
+}
+
+// ColumnValues are the values of the user-defined columns of one task,
+// as JSON, keyed by column name.
+type ColumnValues map[string]json.RawMessage
+
+// Table is one table of a database. Every table lives in the database's
+// single DAGDB: the dag table under the plain DAG IDs of its tasks, so
+// databases created before tables existed keep working unchanged, and a
+// user table under DAG IDs prefixed with tableKeyPrefix. A Table adds the
+// prefix on the way in and strips it on the way out, so callers only ever
+// see the DAG IDs of their own table.
+//
+// The values of user-defined columns are kept out of the tasks, whose
+// Payload stays exactly what was written: each task with column values
+// has a record of its own under columnsDAGID, with the task's ObjectID as
+// ID and the values as a JSON object in Payload.
+//
+// A Table from Database.View also shows the pending writes of a
+// transaction. Only its read methods take them into account.
+type Table struct {
+	name    string
+	prefix  string
+	store   *dagdb.DAGDB
+	pending []TaskWrite
+}
+
+func newTable(store *dagdb.DAGDB, name string) *Table {
+	t := &Table{name: name, store: store}
+	if name != DefaultTable {
+		t.prefix = tableKeyPrefix(name)
+	}
+	return t
+}
+
+// Name returns the name of the table.
+func (t *Table) Name() string {
+	return t.name
+}
+
+// stored returns task as it is kept in the store.
+func (t *Table) stored(task dagdb.DAGTask) dagdb.DAGTask {
+	task.DAGID = t.prefix + task.DAGID
+	return task
+}
+
+// own reports whether a stored task belongs to the table and, if so,
+// returns it with the table prefix stripped.
+func (t *Table) own(task dagdb.DAGTask) (dagdb.DAGTask, bool) {
+	if t.prefix == "" {
+		return task, !strings.HasPrefix(task.DAGID, keySeparator)
+	}
+	if !strings.HasPrefix(task.DAGID, t.prefix) {
+		return task, false
+	}
+	task.DAGID = task.DAGID[len(t.prefix):]
+	return task, true
+}
+
+func (t *Table) filter(tasks []dagdb.DAGTask) []dagdb.DAGTask {
+	var result []dagdb.DAGTask
+	for _, task := range tasks {
+		if task, ok := t.own(task); ok {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// ListTasksByDAG returns the tasks of one DAG of the table.
+func (t *Table) ListTasksByDAG(dagID string) ([]dagdb.DAGTask, error) {
+	if strings.Contains(dagID, keySeparator) {
+		return nil, nil
+	}
+	tasks, err := t.store.ListTasksByDAG(t.prefix + dagID)
+	if err != nil {
+		return nil, err
+	}
+	return t.withPending(t.filter(tasks), func(task dagdb.DAGTask) bool {
+		return task.DAGID == dagID
+	}), nil
+}
+
+// ListAllTasks returns every task of the table. The DAGDB can only list
+// one DAG ID or the whole store, and the DAG IDs of a table are not known
+// up front, so this scans the whole store, the tasks of other tables
+// included, and keeps those of the table. Scanning the table's key prefix
+// alone needs a prefix listing in dagdb.
+func (t *Table) ListAllTasks() ([]dagdb.DAGTask, error) {
+	tasks, err := t.store.ListAllTasks()
+	if err != nil {
+		return nil, err
+	}
+	return t.withPending(t.filter(tasks), func(dagdb.DAGTask) bool {
+		return true
+	}), nil
+}
+
+// QueryByObjectID returns the task of the table with the given ObjectID,
+// if any.
+func (t *Table) QueryByObjectID(objectID string) ([]dagdb.DAGTask, error) {
+	tasks, err := t.store.QueryByObjectID(objectID)
+	if err != nil {
+		return nil, err
+	}
+	return t.withPending(t.filter(tasks), func(task dagdb.DAGTask) bool {
+		return task.ObjectID == objectID
+	}), nil
+}
+
+// withPending applies the pending writes of the table to the stored tasks
+// a read found. Tasks the writes put in place are added if they match.
+func (t *Table) withPending(stored []dagdb.DAGTask, match func(dagdb.DAGTask) bool) []dagdb.DAGTask {
+	if len(t.pending) == 0 {
+		return stored
+	}
+	latest := make(map[string]*dagdb.DAGTask)
+	var order []string
+	for _, w := range t.pending {
+		objectID := w.objectID()
+		if _, seen := latest[objectID]; !seen {
+			order = append(order, objectID)
+		}
+		if w.Kind == WriteDelete {
+			latest[objectID] = nil
+			continue
+		}
+		task := w.New
+		latest[objectID] = &task
+	}
+
+	var result []dagdb.DAGTask
+	for _, task := range stored {
+		if _, written := latest[task.ObjectID]; !written {
+			result = append(result, task)
+		}
+	}
+	for _, objectID := range order {
+		if task := latest[objectID]; task != nil && match(*task) {
+			result = append(result, *task)
+		}
+	}
+	return result
+}
+
+// SaveTask inserts or replaces a task under its (dagid, id) key.
+func (t *Table) SaveTask(task dagdb.DAGTask) error {
+	if err := checkDAGID(task.DAGID); err != nil {
+		return err
+	}
+	return t.store.SaveTask(t.stored(task))
+}
+
+// DeleteTask removes a task.
+func (t *Table) DeleteTask(dagID, id string) error {
+	return t.store.DeleteTask(t.prefix+dagID, id)
+}
+
+// UpdateTaskWithKeyChange replaces old by task under a new key.
+func (t *Table) UpdateTaskWithKeyChange(old, task dagdb.DAGTask) error {
+	if err := checkDAGID(task.DAGID); err != nil {
+		return err
+	}
+	return t.store.UpdateTaskWithKeyChange(t.stored(old), t.stored(task))
+}
+
+// AddGraphTask adds a task of the dag table to the in-memory graph of the
+// store, which dagenie traverse walks. The store has a single graph, so
+// it is kept for the dag table only; the graph statements of every table
+// build the graph of a DAG from its tasks instead (see executor.taskGraph).
+func (t *Table) AddGraphTask(task dagdb.DAGTask) {
+	if t.prefix != "" {
+		return
+	}
+	t.store.Graph().AddTask(task)
+}
+
+// UpdateGraphTask refreshes a task of the dag table in the in-memory
+// graph of the store; see AddGraphTask.
+func (t *Table) UpdateGraphTask(task dagdb.DAGTask) {
+	if t.prefix != "" {
+		return
+	}
+	t.store.UpdateGraphTask(&task)
+}
+
+// ColumnValues returns the stored column values of the table's tasks,
+// keyed by task ObjectID. Tasks without values are left out.
+func (t *Table) ColumnValues() (map[string]ColumnValues, error) {
+	records, err := t.store.ListTasksByDAG(columnsDAGID(t.name))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]ColumnValues, len(records))
+	for _, record := range records {
+		var v ColumnValues
+		if err := json.Unmarshal([]byte(record.Payload), &v); err != nil {
+			return nil, fmt.Errorf("❌ Corrupt column values of task %s: %v", record.ID, err)
+		}
+		if len(v) > 0 {
+			values[record.ID] = v
+		}
+	}
+	for _, w := range t.pending {
+		if w.Kind == WriteDelete || len(w.NewColumns) == 0 {
+			delete(values, w.objectID())
+		} else {
+			values[w.objectID()] = w.NewColumns
+		}
+	}
+	return values, nil
+}
+
+// columnsObjectID is the ObjectID of the column record of a task.
+func columnsObjectID(objectID string) string {
+	return objectID + keySeparator + "columns"
+}
+
+// putColumns stores the column values of a task, or removes its record
+// when there are none.
+func (t *Table) putColumns(objectID string, values ColumnValues) error {
+	if len(values) > 0 {
+		encoded, err := json.Marshal(values)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to encode column values: %v", err)
+		}
+		return t.store.SaveTask(dagdb.DAGTask{
+			ObjectID: columnsObjectID(objectID),
+			DAGID:    columnsDAGID(t.name),
+			ID:       objectID,
+			Payload:  string(encoded),
+		})
+	}
+	found, err := t.store.QueryByObjectID(columnsObjectID(objectID))
+	if err != nil {
+		return err
+	}
+	for _, record := range found {
+		if record.DAGID == columnsDAGID(t.name) {
+			return t.store.DeleteTask(record.DAGID, record.ID)
+		}
+	}
+	return nil
+}
+
+// dropColumnValues removes the values of one column from every record of
+// the table.
+func (t *Table) dropColumnValues(name string) error {
+	values, err := t.ColumnValues()
+	if err != nil {
+		return err
+	}
+	for objectID, v := range values {
+		if _, ok := v[name]; !ok {
+			continue
+		}
+		delete(v, name)
+		if err := t.putColumns(objectID, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equalColumns reports whether two sets of column values are the same.
+func equalColumns(a, b ColumnValues) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, raw := range a {
+		other, ok := b[name]
+		if !ok || !bytes.Equal(raw, other) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDAGID rejects DAG IDs that would reach into the key space of
+// another table.
+func checkDAGID(dagID string) error {
+	if strings.Contains(dagID, keySeparator) {
+		return fmt.Errorf("❌ DAG ID %q contains a reserved control character", dagID)
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 
+	"dagenie/internal/dql/format"
+
 	"github.com/chzyer/readline"
 	"github.com/olekukonko/tablewriter"
 )
@@ -30,7 +32,14 @@ var completer = readline.NewPrefixCompleter(
 
 type dynamicCompleter struct{}
 
-func StartTCPClient(serverAddr string) {
+// StartTCPClient runs the interactive REPL against a server, speaking
+// either the framed json protocol or the human-readable text protocol.
+func StartTCPClient(serverAddr string, mode string) {
+	if mode != ModeJSON && mode != ModeText {
+		fmt.Printf("❌ Unknown protocol mode '%s'. Use 'json' or 'text'.\n", mode)
+		return
+	}
+
 	conn, err := net.Dial("tcp", serverAddr)
 	if err != nil {
 		fmt.Printf("❌ Failed to connect: %v\n", err)
@@ -40,6 +49,30 @@ func StartTCPClient(serverAddr string) {
 	fmt.Printf("🔗 Connected to %s\n", serverAddr)
 
 	currentDB := "default"
+	serverReader := bufio.NewReader(conn)
+
+	// Negotiate the protocol before the first query
+	if _, err := conn.Write([]byte(Handshake(mode))); err != nil {
+		fmt.Printf("❌ Write error: %v\n", err)
+		return
+	}
+	if mode == ModeJSON {
+		var hello Response
+		if err := ReadFrame(serverReader, &hello); err != nil {
+			fmt.Printf("❌ Handshake failed: %v\n", err)
+			return
+		}
+		if hello.Status != "hello" {
+			fmt.Printf("❌ Handshake rejected: %s\n", hello.Status)
+			return
+		}
+		if hello.Database != "" {
+			currentDB = hello.Database
+		}
+	} else if _, err := readTextResponse(serverReader); err != nil {
+		fmt.Printf("❌ Handshake failed: %v\n", err)
+		return
+	}
 
 	rl, err := readline.NewEx(&readline.Config{
 		Prompt:            fmt.Sprintf("📝 [%s] DQL > ", currentDB),
@@ -55,8 +88,8 @@ func StartTCPClient(serverAddr string) {
 	}
 	defer rl.Close()
 
-	serverReader := bufio.NewReader(conn)
 	var buffer strings.Builder
+	outputFormat := format.DefaultFormat
 
 	for {
 		rl.SetPrompt(fmt.Sprintf("📝 [%s] DQL > ", currentDB))
@@ -68,73 +101,153 @@ func StartTCPClient(serverAddr string) {
 		line = strings.TrimSpace(line)
 
 		if strings.ToLower(line) == "exit" {
-			conn.Write([]byte("exit\n"))
+			if mode == ModeJSON {
+				WriteFrame(conn, Request{Type: "exit"})
+			} else {
+				conn.Write([]byte("exit\n"))
+			}
 			break
 		}
 
+		// \format <name> switches how results are rendered
+		if buffer.Len() == 0 && strings.HasPrefix(line, "\\format") {
+			outputFormat = switchFormat(mode, outputFormat, strings.TrimSpace(strings.TrimPrefix(line, "\\format")))
+			continue
+		}
+
 		buffer.WriteString(line + " ")
 
 		if strings.HasSuffix(line, ";") {
-			query := strings.TrimSuffix(buffer.String(), ";")
+			query := strings.TrimSuffix(strings.TrimSpace(buffer.String()), ";")
 			buffer.Reset()
 
-			_, err = conn.Write([]byte(query + "\n"))
+			if mode == ModeJSON {
+				err = runJSONQuery(conn, serverReader, query, outputFormat, &currentDB)
+			} else {
+				err = runTextQuery(conn, serverReader, query, &currentDB)
+			}
 			if err != nil {
-				fmt.Printf("❌ Write error: %v\n", err)
+				fmt.Printf("❌ %v\n", err)
 				break
 			}
+		}
+	}
+}
 
-			var responseLines []string
-			for {
-				resp, err := serverReader.ReadString('\n')
-				if err != nil {
-					fmt.Printf("❌ Read error: %v\n", err)
-					break
-				}
-				resp = strings.TrimRight(resp, "\r\n")
-
-				// Update DB prompt if DB switched
-				if strings.HasPrefix(resp, "✅ Using database") {
-					parts := strings.Split(resp, "'")
-					if len(parts) >= 2 {
-						currentDB = parts[1]
-						rl.SetPrompt(fmt.Sprintf("📝 [%s] DQL > ", currentDB))
-					}
-				}
-
-				// Wait until we get 📥 Ready for next query... before proceeding
-				if resp == "📥 Ready for next query..." {
-					break // Stop reading; ready for next user query
-				}
-
-				// Collect response for display
-				responseLines = append(responseLines, resp)
-			}
+// switchFormat handles the \format command and returns the format to use
+// from now on. Without an argument it prints the current and available
+// formats.
+func switchFormat(mode, current, name string) string {
+	if name == "" {
+		fmt.Printf("🖨️ Output format: %s (available: %s)\n", current, strings.Join(format.Names(), ", "))
+		return current
+	}
+	if mode != ModeJSON {
+		fmt.Println("\033[31m❌ Output formats need the json protocol (connect with --mode json)\033[0m")
+		return current
+	}
+	if _, err := format.Lookup(name); err != nil {
+		fmt.Println("\033[31m" + err.Error() + "\033[0m")
+		return current
+	}
+	fmt.Printf("\033[32m✅ Output format set to %s\033[0m\n", strings.ToLower(name))
+	return strings.ToLower(name)
+}
 
-			// Detect key=value lines for table display
-			hasKeyValue := false
-			for _, line := range responseLines {
-				if strings.Contains(line, "=") && !strings.HasPrefix(line, "✅") && !strings.HasPrefix(line, "❌") {
-					hasKeyValue = true
-					break
-				}
-			}
+// runJSONQuery sends one query as a frame and prints the response frame
+// using the selected output format.
+func runJSONQuery(conn net.Conn, serverReader *bufio.Reader, query, outputFormat string, currentDB *string) error {
+	if err := WriteFrame(conn, Request{Type: "query", Query: query}); err != nil {
+		return fmt.Errorf("Write error: %v", err)
+	}
+	var resp Response
+	if err := ReadFrame(serverReader, &resp); err != nil {
+		return fmt.Errorf("Read error: %v", err)
+	}
 
-			// Print result
-			if hasKeyValue {
-				printAsTable(responseLines)
-			} else {
-				for _, line := range responseLines {
-					if strings.HasPrefix(line, "✅") {
-						fmt.Println("\033[32m" + line + "\033[0m")
-					} else if strings.HasPrefix(line, "❌") {
-						fmt.Println("\033[31m" + line + "\033[0m")
-					} else {
-						fmt.Println(line)
-					}
-				}
+	if resp.Database != "" {
+		*currentDB = resp.Database
+	}
+	if resp.Status == "error" && resp.Error != nil {
+		fmt.Printf("\033[31m[%s] %s\033[0m\n", resp.Error.Code, resp.Error.Message)
+		return nil
+	}
+	rendered, err := format.Render(outputFormat, resp.ResultSet())
+	if err != nil {
+		return err
+	}
+	printLines(strings.Split(strings.TrimRight(rendered, "\n"), "\n"))
+	return nil
+}
+
+// readTextResponse reads text protocol lines up to the ready marker.
+func readTextResponse(serverReader *bufio.Reader) ([]string, error) {
+	var responseLines []string
+	for {
+		resp, err := serverReader.ReadString('\n')
+		if err != nil {
+			return responseLines, err
+		}
+		resp = strings.TrimRight(resp, "\r\n")
+
+		// Wait until we get 📥 Ready for next query... before proceeding
+		if resp == readyMarker {
+			return responseLines, nil // Stop reading; ready for next user query
+		}
+
+		// Collect response for display
+		responseLines = append(responseLines, resp)
+	}
+}
+
+// runTextQuery sends one query line and prints the text response.
+func runTextQuery(conn net.Conn, serverReader *bufio.Reader, query string, currentDB *string) error {
+	if _, err := conn.Write([]byte(query + "\n")); err != nil {
+		return fmt.Errorf("Write error: %v", err)
+	}
+
+	responseLines, err := readTextResponse(serverReader)
+	if err != nil {
+		return fmt.Errorf("Read error: %v", err)
+	}
+
+	// Update DB prompt if DB switched
+	for _, resp := range responseLines {
+		if strings.HasPrefix(resp, "✅ Using database") {
+			parts := strings.Split(resp, "'")
+			if len(parts) >= 2 {
+				*currentDB = parts[1]
 			}
+		}
+	}
+
+	// Detect key=value lines for table display
+	hasKeyValue := false
+	for _, line := range responseLines {
+		if strings.Contains(line, "=") && !strings.HasPrefix(line, "✅") && !strings.HasPrefix(line, "❌") {
+			hasKeyValue = true
+			break
+		}
+	}
+
+	// Print result
+	if hasKeyValue {
+		printAsTable(responseLines)
+	} else {
+		printLines(responseLines)
+	}
+	return nil
+}
 
+// printLines prints response lines, colouring successes and failures.
+func printLines(lines []string) {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "✅") {
+			fmt.Println("\033[32m" + line + "\033[0m")
+		} else if strings.HasPrefix(line, "❌") {
+			fmt.Println("\033[31m" + line + "\033[0m")
+		} else {
+			fmt.Println(line)
 		}
 	}
 }
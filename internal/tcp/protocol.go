@@ -0,0 +1,187 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"dagenie/internal/dql/executor"
+)
+
+// Wire protocol
+//
+// A client opens a session by sending one handshake line:
+//
+//	DAGENIE/<version> <mode>\n
+//
+// where mode is "json" or "text". In text mode the server behaves like the
+// original line protocol: each query is one line and each response ends
+// with the readyMarker line. In json mode every message in both
+// directions is a frame: a 4-byte big-endian length followed by that many
+// bytes of JSON. The server answers the handshake with a Response whose
+// Status is "hello" and whose Version is the negotiated protocol version.
+//
+// Connections that start with anything else are served in text mode, so
+// plain line-based clients keep working.
+
+const (
+	// ProtocolVersion is the highest wire protocol version this server speaks.
+	ProtocolVersion = 1
+
+	ModeJSON = "json"
+	ModeText = "text"
+
+	handshakePrefix = "DAGENIE/"
+	readyMarker     = "📥 Ready for next query..."
+	maxFrameSize    = 16 << 20
+)
+
+// Request is a client → server frame.
+type Request struct {
+	Type  string `json:"type"` // "query" or "exit"
+	Query string `json:"query,omitempty"`
+}
+
+// Column describes one column of a result set.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ErrorInfo describes a failed request. Retryable is set when the request
+// may succeed if sent again, as after a write conflict.
+type ErrorInfo struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable,omitempty"`
+}
+
+// Response is a server → client frame.
+type Response struct {
+	Version      int             `json:"version"`
+	Status       string          `json:"status"` // "hello", "ok", "error" or "bye"
+	Error        *ErrorInfo      `json:"error,omitempty"`
+	Database     string          `json:"database,omitempty"`
+	Message      string          `json:"message,omitempty"`
+	Columns      []Column        `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	RowsAffected int             `json:"rows_affected,omitempty"`
+	ElapsedMs    float64         `json:"elapsed_ms,omitempty"`
+}
+
+// ResultSet rebuilds the executor result carried by an "ok" response, so
+// clients can render it with any formatter.
+func (r *Response) ResultSet() *executor.ResultSet {
+	rs := &executor.ResultSet{
+		Rows:         r.Rows,
+		RowsAffected: r.RowsAffected,
+		Message:      r.Message,
+		Elapsed:      time.Duration(r.ElapsedMs * float64(time.Millisecond)),
+	}
+	for _, col := range r.Columns {
+		rs.Columns = append(rs.Columns, executor.Column{Name: col.Name, Type: executor.ColumnType(col.Type)})
+	}
+	return rs
+}
+
+// ErrFrameTooLarge is returned for a frame over the 16 MiB limit.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// WriteFrame encodes v as JSON and writes it as one length-prefixed frame.
+func WriteFrame(w io.Writer, v interface{}) error {
+	payload, err := encodeFrame(v)
+	if err != nil {
+		return err
+	}
+	return writePayload(w, payload)
+}
+
+// encodeFrame encodes v as the JSON payload of one frame, checking its
+// size before anything is written.
+func encodeFrame(v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxFrameSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, len(payload))
+	}
+	return payload, nil
+}
+
+// writePayload writes an encoded payload as one length-prefixed frame.
+func writePayload(w io.Writer, payload []byte) error {
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// ReadFrame reads one length-prefixed frame and decodes its JSON into v.
+func ReadFrame(r io.Reader, v interface{}) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// Handshake returns the line a client sends to open a session.
+func Handshake(mode string) string {
+	return fmt.Sprintf("%s%d %s\n", handshakePrefix, ProtocolVersion, mode)
+}
+
+// parseHandshake recognises a handshake line and returns the negotiated
+// version and mode. ok is false for lines that are not handshakes.
+func parseHandshake(line string) (version int, mode string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(strings.ToUpper(line), handshakePrefix) {
+		return 0, "", false, nil
+	}
+	fields := strings.Fields(line[len(handshakePrefix):])
+	if len(fields) == 0 {
+		return 0, "", true, fmt.Errorf("malformed handshake: %q", line)
+	}
+
+	requested, convErr := strconv.Atoi(fields[0])
+	if convErr != nil || requested < 1 {
+		return 0, "", true, fmt.Errorf("unsupported protocol version: %s", fields[0])
+	}
+	version = requested
+	if version > ProtocolVersion {
+		version = ProtocolVersion
+	}
+
+	mode = ModeText
+	if len(fields) > 1 {
+		mode = strings.ToLower(fields[1])
+	}
+	if mode != ModeJSON && mode != ModeText {
+		return 0, "", true, fmt.Errorf("unsupported protocol mode: %s", mode)
+	}
+	return version, mode, true, nil
+}
+
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// stripANSI removes terminal colour codes from text sent to json clients.
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
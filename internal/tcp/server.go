@@ -2,9 +2,12 @@ package tcp
 
 import (
 	"bufio"
-	"dagenie/internal/dagdb"
 	"dagenie/internal/dql"
+	"dagenie/internal/dql/format"
+	"dagenie/internal/dql/storage"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 )
@@ -20,7 +23,7 @@ const dagBanner = `
 ----------- DAGenie LightSpeed Server -----------
 `
 
-func StartTCPServer(db *dagdb.DAGDB, address string) error {
+func StartTCPServer(db *storage.Database, manager *dql.Manager, address string) error {
 	// 🟢 Print banner in green
 	fmt.Println(green + dagBanner + reset)
 
@@ -36,7 +39,7 @@ func StartTCPServer(db *dagdb.DAGDB, address string) error {
 			fmt.Printf("❌ Connection error: %v\n", err)
 			continue
 		}
-		go handleConnection(conn, db)
+		go handleConnection(conn, db, manager)
 	}
 }
 
@@ -106,17 +109,51 @@ func StartTCPServer(db *dagdb.DAGDB, address string) error {
 }
 */
 
-func handleConnection(conn net.Conn, globalDB *dagdb.DAGDB) {
+func handleConnection(conn net.Conn, globalDB *storage.Database, manager *dql.Manager) {
 	defer conn.Close()
 	reader := bufio.NewReader(conn)
+	sess := dql.NewSession(manager, globalDB)
+	defer sess.Close()
 
-	var clientDB *dagdb.DAGDB = globalDB // default DB
+	// The first line is either a protocol handshake or, for plain line
+	// clients, already the first query.
+	firstLine, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Write([]byte("❌ Error reading query\n"))
+		return
+	}
+	version, mode, isHandshake, err := parseHandshake(firstLine)
+	if err != nil {
+		conn.Write([]byte(fmt.Sprintf("❌ %v\n", err)))
+		return
+	}
+
+	if isHandshake && mode == ModeJSON {
+		serveJSON(conn, reader, sess, version)
+		return
+	}
 
+	pending := firstLine
+	if isHandshake {
+		pending = ""
+		conn.Write([]byte(fmt.Sprintf("✅ %s%d %s\n%s\n", handshakePrefix, version, ModeText, readyMarker)))
+	}
+	serveText(conn, reader, sess, pending)
+}
+
+// serveText runs the human-readable line protocol: one query per line,
+// each response terminated by readyMarker.
+func serveText(conn net.Conn, reader *bufio.Reader, sess *dql.Session, pending string) {
 	for {
-		queryLine, err := reader.ReadString('\n')
-		if err != nil {
-			conn.Write([]byte("❌ Error reading query\n"))
-			return
+		queryLine := pending
+		pending = ""
+		if queryLine == "" {
+			var err error
+			queryLine, err = reader.ReadString('\n')
+			if err != nil {
+				conn.Write([]byte("❌ Error reading query\n"))
+				return
+			}
 		}
 
 		queryLine = strings.TrimSpace(queryLine)
@@ -125,24 +162,83 @@ func handleConnection(conn net.Conn, globalDB *dagdb.DAGDB) {
 			conn.Write([]byte("👋 Bye!\n"))
 			return
 		}
+
 		fmt.Printf("📨 Received query: %s\n", queryLine)
+		result, err := sess.Execute(queryLine)
+		if err == nil {
+			var rendered string
+			if rendered, err = format.Render(format.DefaultFormat, result); err == nil {
+				conn.Write([]byte(rendered))
+			}
+		}
+		if err != nil {
+			conn.Write([]byte(fmt.Sprintf("❌ %v\n", err)))
+		}
+		conn.Write([]byte(readyMarker + "\n"))
+	}
+}
 
-		// Pass client-specific DB
-		result, newDB, err := dql.ExecuteDQLWithContext(clientDB, queryLine)
-		if newDB != nil {
-			// Close old clientDB if needed
-			if clientDB != globalDB {
-				clientDB.Close()
+// serveJSON runs the framed protocol: one Request frame in, one Response
+// frame out.
+func serveJSON(conn net.Conn, reader *bufio.Reader, sess *dql.Session, version int) {
+	if err := WriteFrame(conn, Response{Version: version, Status: "hello", Database: sess.Database()}); err != nil {
+		return
+	}
+
+	for {
+		var req Request
+		if err := ReadFrame(reader, &req); err != nil {
+			if err != io.EOF {
+				fmt.Printf("❌ Frame read error: %v\n", err)
 			}
-			clientDB = newDB
+			return
 		}
 
+		switch req.Type {
+		case "exit":
+			WriteFrame(conn, Response{Version: version, Status: "bye"})
+			return
+		case "query":
+		default:
+			resp := Response{Version: version, Status: "error", Error: &ErrorInfo{Code: "BAD_REQUEST", Message: fmt.Sprintf("unknown request type %q", req.Type)}}
+			if err := WriteFrame(conn, resp); err != nil {
+				return
+			}
+			continue
+		}
+
+		queryLine := strings.TrimSuffix(strings.TrimSpace(req.Query), ";")
+		fmt.Printf("📨 Received query: %s\n", queryLine)
+		result, err := sess.Execute(queryLine)
+
+		resp := Response{Version: version, Status: "ok", Database: sess.Database()}
 		if err != nil {
-			conn.Write([]byte(fmt.Sprintf("❌ %v\n", err)))
+			resp.Status = "error"
+			resp.Error = &ErrorInfo{Code: dql.ErrorCode(err), Message: stripANSI(err.Error()), Retryable: dql.IsRetryable(err)}
 		} else {
-			conn.Write([]byte(result + "\n"))
-
+			resp.Message = stripANSI(result.Message)
+			resp.RowsAffected = result.RowsAffected
+			resp.ElapsedMs = float64(result.Elapsed.Microseconds()) / 1000
+			for _, col := range result.Columns {
+				resp.Columns = append(resp.Columns, Column{Name: col.Name, Type: string(col.Type)})
+			}
+			resp.Rows = result.Rows
+		}
+		// A result over the frame limit is replaced by an error, so the
+		// connection stays usable
+		payload, err := encodeFrame(resp)
+		if errors.Is(err, ErrFrameTooLarge) {
+			payload, err = encodeFrame(Response{Version: version, Status: "error", Database: sess.Database(), Error: &ErrorInfo{
+				Code:    "RESULT_TOO_LARGE",
+				Message: fmt.Sprintf("❌ The result of %d row(s) is over the %d MiB frame limit. The statement ran, but its result cannot be sent: use LIMIT to read fewer rows", len(resp.Rows), maxFrameSize>>20),
+			}})
+		}
+		if err == nil {
+			err = writePayload(conn, payload)
+		}
+		if err != nil {
+			fmt.Printf("❌ Frame write error: %v\n", err)
+			return
 		}
-		conn.Write([]byte("📥 Ready for next query...\n"))
 	}
 }